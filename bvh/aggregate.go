@@ -0,0 +1,173 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Aggregator summarizes the volumes below each node of a BVH, such as their count, total mass or maximum priority.
+// Combine must be associative and commutative, since rebalancing changes how the volumes are grouped.
+type Aggregator[T any, V any] struct {
+	Identity V
+	Combine  func(first, second V) V
+	Leaf     func(orth T) V
+}
+
+// augment stores the aggregate of a volume. It is an interface so that BVol does not need the type of the value. The
+// augments of a volume form a list, one for each slot, so that the values of features such as Merkle are kept
+// alongside the Aggregate from Augment.
+type augment[T math32.VolumeType[E], E math32.Number] interface {
+	// reaggregate recalculates the values of the list for bvol from its children, or from its volume for a leaf.
+	reaggregate(bvol *BVol[T, E])
+	// clone returns a copy of the list with the same values.
+	clone() augment[T, E]
+	// without returns the list without the augment in slot.
+	without(slot augSlot) augment[T, E]
+	// following returns the rest of the list, or nil.
+	following() augment[T, E]
+}
+
+// augSlot identifies the feature that an augment is kept for.
+type augSlot uint8
+
+const (
+	augAggregate augSlot = iota
+	augMerkle
+)
+
+// augValue implements augment for an Aggregator.
+type augValue[T math32.VolumeType[E], E math32.Number, V any] struct {
+	agg   *Aggregator[T, V]
+	value V
+	slot  augSlot
+	next  augment[T, E]
+}
+
+func (a *augValue[T, E, V]) reaggregate(bvol *BVol[T, E]) {
+	if bvol.depth > 0 {
+		a.value = a.agg.Combine(valueOf(bvol.desc[0], a.agg), valueOf(bvol.desc[1], a.agg))
+	} else if bvol.vol.IsNil() {
+		a.value = a.agg.Identity
+	} else {
+		a.value = a.agg.Leaf(bvol.vol)
+	}
+	if a.next != nil {
+		a.next.reaggregate(bvol)
+	}
+}
+
+func (a *augValue[T, E, V]) clone() augment[T, E] {
+	copied := *a
+	if a.next != nil {
+		copied.next = a.next.clone()
+	}
+	return &copied
+}
+
+func (a *augValue[T, E, V]) without(slot augSlot) augment[T, E] {
+	if a.slot == slot {
+		return a.next
+	}
+	if a.next != nil {
+		a.next = a.next.without(slot)
+	}
+	return a
+}
+
+func (a *augValue[T, E, V]) following() augment[T, E] {
+	return a.next
+}
+
+// aug returns the list of aggregates of the volume, or nil.
+func (b *BVol[T, E]) aug() augment[T, E] {
+	if b.opt == nil {
+		return nil
+	}
+	return b.opt.aug
+}
+
+// setAug changes the list of aggregates of the volume, which creates its options unless it is nil.
+func (b *BVol[T, E]) setAug(aug augment[T, E]) {
+	if b.opt != nil || aug != nil {
+		b.options().aug = aug
+	}
+}
+
+// valueOf returns the value of agg stored in bvol, or its identity once the BVH has been augmented again.
+func valueOf[T math32.VolumeType[E], E math32.Number, V any](bvol *BVol[T, E], agg *Aggregator[T, V]) V {
+	for aug := bvol.aug(); aug != nil; aug = aug.following() {
+		if value, ok := aug.(*augValue[T, E, V]); ok && value.agg == agg {
+			return value.value
+		}
+	}
+	return agg.Identity
+}
+
+// Aggregate gives the values of an Aggregator for a BVH (see Augment).
+type Aggregate[T math32.VolumeType[E], E math32.Number, V any] struct {
+	bvh   *BVol[T, E]
+	agg   *Aggregator[T, V]
+	stack []*BVol[T, E]
+}
+
+// Augment calculates the values of agg for every volume of the BVH. Afterwards they are updated whenever the BVH is
+// rebalanced, by any iterator, so that region queries need not visit every leaf. A BVH has one aggregator at a time;
+// augmenting it again replaces the previous one, whose values are then its identity. A Merkle is kept separately.
+func Augment[T math32.VolumeType[E], E math32.Number, V any](b *BVol[T, E], agg Aggregator[T, V]) *Aggregate[T, E, V] {
+	return augmentSlot(b, agg, augAggregate)
+}
+
+// augmentSlot attaches the values of agg to the BVH in place of any augment in slot.
+func augmentSlot[T math32.VolumeType[E], E math32.Number, V any](b *BVol[T, E], agg Aggregator[T, V],
+	slot augSlot) *Aggregate[T, E, V] {
+	a := &Aggregate[T, E, V]{bvh: b, agg: &agg}
+	a.augment(b, slot)
+	return a
+}
+
+// augment attaches values to bvol and its descendants in post-order.
+func (a *Aggregate[T, E, V]) augment(bvol *BVol[T, E], slot augSlot) {
+	if bvol.depth > 0 {
+		a.augment(bvol.desc[0], slot)
+		a.augment(bvol.desc[1], slot)
+	}
+	aug := &augValue[T, E, V]{agg: a.agg, slot: slot}
+	if existing := bvol.aug(); existing != nil {
+		aug.next = existing.without(slot)
+	}
+	aug.reaggregate(bvol)
+	bvol.setAug(aug)
+}
+
+// Value returns the aggregate of every volume in the BVH, or the identity when it is empty.
+func (a *Aggregate[T, E, V]) Value() V {
+	return a.at(a.bvh)
+}
+
+// at returns the aggregate of the volumes at or below bvol.
+func (a *Aggregate[T, E, V]) at(bvol *BVol[T, E]) V {
+	return valueOf(bvol, a.agg)
+}
+
+// Query returns the aggregate of the volumes that overlap o. Branches contained by o contribute their stored value
+// without being visited. An Aggregate is not thread-safe.
+func (a *Aggregate[T, E, V]) Query(o T) V {
+	total := a.agg.Identity
+	if a.bvh.vol.IsNil() {
+		return total
+	}
+
+	a.stack = append(a.stack[:0], a.bvh)
+	for len(a.stack) > 0 {
+		bvol := a.stack[len(a.stack)-1]
+		a.stack = a.stack[:len(a.stack)-1]
+		if !bvol.vol.Overlaps(o) {
+			continue
+		}
+		if bvol.depth == 0 || o.Contains(bvol.vol) {
+			total = a.agg.Combine(total, a.at(bvol))
+			continue
+		}
+		a.stack = append(a.stack, bvol.desc[1], bvol.desc[0])
+	}
+	return total
+}
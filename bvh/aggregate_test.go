@@ -0,0 +1,123 @@
+package collision
+
+import (
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// massAggregator totals the count and volume of orthotopes.
+var massAggregator = Aggregator[*Orthotope[int32], [2]int64]{
+	Combine: func(first, second [2]int64) [2]int64 {
+		return [2]int64{first[0] + second[0], first[1] + second[1]}
+	},
+	Leaf: func(orth *Orthotope[int32]) [2]int64 {
+		mass := int64(1)
+		for _, d := range orth.Delta {
+			mass *= int64(d)
+		}
+		return [2]int64{1, mass}
+	},
+}
+
+// checkAggregate verifies the value of every volume against its leaves.
+func checkAggregate(t *testing.T, agg *Aggregate[*Orthotope[int32], int32, [2]int64],
+	tree *BVol[*Orthotope[int32], int32]) {
+	var total func(bvol *BVol[*Orthotope[int32], int32]) [2]int64
+	total = func(bvol *BVol[*Orthotope[int32], int32]) [2]int64 {
+		var value [2]int64
+		if bvol.depth > 0 {
+			value = massAggregator.Combine(total(bvol.desc[0]), total(bvol.desc[1]))
+		} else if !bvol.vol.IsNil() {
+			value = massAggregator.Leaf(bvol.vol)
+		}
+		if stored := agg.at(bvol); stored != value {
+			t.Errorf("Volume %v has aggregate %v, expected %v", bvol.vol, stored, value)
+		}
+		return value
+	}
+	total(tree)
+}
+
+func TestAggregate(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	agg := Augment(tree, massAggregator)
+	if agg.Value() != [2]int64{} {
+		t.Errorf("Empty tree has aggregate %v", agg.Value())
+	}
+
+	iter := NewArena[*Orthotope[int32], int32](0).Iterator(tree)
+	for _, orth := range orths[:800] {
+		iter.Add(orth)
+	}
+	for _, orth := range orths[:300] {
+		iter.Remove(orth)
+	}
+	for _, orth := range orths[800:] {
+		tree.Add(orth)
+	}
+	checkAggregate(t, agg, tree)
+	if agg.Value()[0] != 700 {
+		t.Errorf("Expected to count 700 volumes, got %d", agg.Value()[0])
+	}
+
+	queries := randomOrths(100)
+	for _, q := range queries {
+		q.Delta = Coordinate[int32](q.Delta).Scale(10)
+		var expected [2]int64
+		iter.Reset()
+		for found := iter.Query(q); found != nil; found = iter.Query(q) {
+			expected = massAggregator.Combine(expected, massAggregator.Leaf(found))
+		}
+		if value := agg.Query(q); value != expected {
+			t.Errorf("Aggregate of %v was %v, expected %v", q.String(), value, expected)
+		}
+	}
+
+	for _, orth := range orths[300:] {
+		iter.Remove(orth)
+	}
+	if agg.Value() != [2]int64{} {
+		t.Errorf("Empty tree has aggregate %v", agg.Value())
+	}
+}
+
+func TestAggregateBuilt(t *testing.T) {
+	orths := randomOrths(500)
+	tree := BinnedSAHBVH[*Orthotope[int32], int32](orths[:400], 4)
+	agg := Augment(tree, massAggregator)
+	checkAggregate(t, agg, tree)
+	for _, orth := range orths[400:] {
+		tree.Add(orth)
+	}
+	for _, orth := range orths[:100] {
+		tree.Remove(orth)
+	}
+	checkAggregate(t, agg, tree)
+	if agg.Value()[0] != 400 {
+		t.Errorf("Expected to count 400 volumes, got %d", agg.Value()[0])
+	}
+}
+
+func TestAggregateSnapshot(t *testing.T) {
+	orths := randomOrths(600)
+	tree := NewPersistentBVol[*Orthotope[int32], int32]()
+	agg := Augment(tree.iter.bvh, massAggregator)
+	for _, orth := range orths[:300] {
+		tree.Add(orth)
+	}
+	snap := tree.Snapshot()
+	expected := agg.at(snap.root)
+	for _, orth := range orths[300:] {
+		tree.Add(orth)
+	}
+	for _, orth := range orths[:200] {
+		tree.Remove(orth)
+	}
+	if value := agg.at(snap.root); value != expected {
+		t.Errorf("Snapshot aggregate changed from %v to %v", expected, value)
+	}
+	checkAggregate(t, agg, snap.root)
+	checkAggregate(t, agg, tree.iter.bvh)
+}
@@ -0,0 +1,115 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// arenaChunk is the number of nodes an Arena allocates at a time.
+const arenaChunk int = 256
+
+// Arena recycles the nodes and internal volumes of a BVH. Iterators created via Arena.Iterator take new nodes from
+// the arena when adding and return them when removing, so that a BVH of a steady size does not allocate. An Arena,
+// like the iterators it creates, is not thread-safe.
+type Arena[T math32.VolumeType[E], E math32.Number] struct {
+	nodes []*BVol[T, E]
+	vols  []T
+	chunk []BVol[T, E]
+}
+
+// NewArena creates an Arena with room for capacity nodes before it needs to allocate.
+func NewArena[T math32.VolumeType[E], E math32.Number](capacity int) *Arena[T, E] {
+	return &Arena[T, E]{
+		nodes: make([]*BVol[T, E], 0, capacity),
+		vols:  make([]T, 0, capacity),
+		chunk: make([]BVol[T, E], capacity),
+	}
+}
+
+// Iterator for the Bounding Volume Hierarchy, b, that allocates from the arena. Reuse the iterator for every
+// operation on b to avoid allocations.
+func (a *Arena[T, E]) Iterator(b *BVol[T, E]) *orthStack[T, E] {
+	stack := b.Iterator()
+	stack.arena = a
+	return stack
+}
+
+// node returns a recycled node, or one from the current chunk.
+func (a *Arena[T, E]) node() *BVol[T, E] {
+	if len(a.nodes) > 0 {
+		bvol := a.nodes[len(a.nodes)-1]
+		a.nodes = a.nodes[:len(a.nodes)-1]
+		return bvol
+	}
+	if len(a.chunk) == 0 {
+		a.chunk = make([]BVol[T, E], arenaChunk)
+	}
+	bvol := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return bvol
+}
+
+// volume returns a recycled internal volume, or a New one like orth.
+func (a *Arena[T, E]) volume(orth T) T {
+	if len(a.vols) > 0 {
+		vol := a.vols[len(a.vols)-1]
+		a.vols = a.vols[:len(a.vols)-1]
+		return vol
+	}
+	return orth.New().(T)
+}
+
+// release a node that is no longer part of the tree.
+func (a *Arena[T, E]) release(bvol *BVol[T, E]) {
+	*bvol = BVol[T, E]{}
+	a.nodes = append(a.nodes, bvol)
+}
+
+// newNode creates a leaf node for orth in the given layers, using the arena if there is one.
+func (s *orthStack[T, E]) newNode(orth T, layers uint64) *BVol[T, E] {
+	if s.arena == nil {
+		bvol := newLeaf[T, E](orth, layers)
+		if s.cow != nil {
+			bvol.gen = s.cow.gen
+		}
+		return bvol
+	}
+	bvol := s.arena.node()
+	bvol.vol, bvol.count = orth, 1
+	bvol.setLayers(layers, layers)
+	return bvol
+}
+
+// newVol creates an internal volume like orth, using the arena if there is one.
+func (s *orthStack[T, E]) newVol(orth T) T {
+	if s.arena == nil {
+		return orth.New().(T)
+	}
+	return s.arena.volume(orth)
+}
+
+// release a node removed from the tree to the arena if there is one. Internal volumes are recycled as well.
+func (s *orthStack[T, E]) release(bvol *BVol[T, E]) {
+	if s.arena != nil {
+		if bvol.depth > 0 {
+			s.arena.vols = append(s.arena.vols, bvol.vol)
+		}
+		s.arena.release(bvol)
+	}
+}
+
+// recycle an internal volume that is no longer part of the tree.
+func (s *orthStack[T, E]) recycle(vol T) {
+	if s.arena != nil {
+		s.arena.vols = append(s.arena.vols, vol)
+	}
+}
+
+// minBoundsPair sets vol to the minimum bounds of first and second, avoiding the variadic slice of MinBounds when vol
+// is a math32.PairBounder.
+func minBoundsPair[T math32.VolumeType[E], E math32.Number](vol, first, second T) {
+	if pair, ok := any(vol).(math32.PairBounder[E]); ok {
+		pair.MinBoundsPair(first, second)
+	} else {
+		vol.MinBounds(first, second)
+	}
+}
@@ -0,0 +1,138 @@
+package collision
+
+import (
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestArena(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	arena := NewArena[*Orthotope[int32], int32](16)
+	iter := arena.Iterator(tree)
+
+	for _, orth := range orths {
+		if !iter.Add(orth) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths[:600] {
+		if !iter.Remove(orth) {
+			t.Errorf("Unable to remove: %v\n", orth.String())
+		}
+	}
+	// Each removal frees a leaf node and its parent.
+	if len(arena.nodes) != 1200 || len(arena.vols) != 600 {
+		t.Errorf("Expected 1200 nodes and 600 volumes, got %d and %d", len(arena.nodes), len(arena.vols))
+	}
+	for _, orth := range orths[:600] {
+		if !iter.Add(orth) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	if len(arena.nodes) != 0 || len(arena.vols) != 0 {
+		t.Errorf("Expected recycled nodes to be reused, %d and %d remain", len(arena.nodes), len(arena.vols))
+	}
+	checkBounds(t, tree)
+
+	for _, orth := range orths {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths {
+		if !iter.Remove(orth) {
+			t.Errorf("Unable to remove: %v\n", orth.String())
+		}
+	}
+	if tree.depth != 0 || !tree.vol.IsNil() {
+		t.Errorf("Expected an empty tree:\n%v", tree.String())
+	}
+}
+
+func TestArenaAllocs(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := NewArena[*Orthotope[int32], int32](0).Iterator(tree)
+	for _, orth := range orths {
+		iter.Add(orth)
+	}
+
+	// Warm up the free lists and the stack.
+	for _, orth := range orths {
+		iter.Remove(orth)
+		iter.Add(orth)
+	}
+
+	i := 0
+	allocs := testing.AllocsPerRun(len(orths), func() {
+		iter.Remove(orths[i])
+		iter.Add(orths[i])
+		i = (i + 1) % len(orths)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations for Add/Remove, got %v", allocs)
+	}
+
+	allocs = testing.AllocsPerRun(len(orths), func() {
+		iter.Reset()
+		for r := iter.Query(orths[i]); r != nil; r = iter.Query(orths[i]) {
+		}
+		i = (i + 1) % len(orths)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations for Query, got %v", allocs)
+	}
+}
+
+func TestPooledAllocs(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	for _, orth := range orths {
+		tree.Add(orth)
+	}
+
+	// Without an arena, only the new leaf, its parent and the parent's volume are allocated.
+	i := 0
+	allocs := testing.AllocsPerRun(len(orths), func() {
+		tree.Remove(orths[i])
+		tree.Add(orths[i])
+		i = (i + 1) % len(orths)
+	})
+	if allocs > 3 && !raceEnabled {
+		t.Errorf("Expected at most 3 allocations for Add/Remove, got %v", allocs)
+	}
+	checkBounds(t, tree)
+}
+
+func BenchmarkArenaAddRemove(b *testing.B) {
+	orths := randomOrths(10000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := NewArena[*Orthotope[int32], int32](len(orths) * 2).Iterator(tree)
+	for _, orth := range orths {
+		iter.Add(orth)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		orth := orths[i%len(orths)]
+		iter.Remove(orth)
+		iter.Add(orth)
+	}
+}
+
+func BenchmarkAddRemove(b *testing.B) {
+	orths := randomOrths(10000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	for _, orth := range orths {
+		tree.Add(orth)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		orth := orths[i%len(orths)]
+		tree.Remove(orth)
+		tree.Add(orth)
+	}
+}
@@ -0,0 +1,123 @@
+package collision
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// batchChunk is the number of consecutive queries a goroutine takes at a time in BatchQuery and BatchNearest.
+const batchChunk int = 64
+
+// querier gives the methods of an iterator used by BatchQuery. It is implemented by the iterators of BVol, Snapshot,
+// FlatBVH and BVH4.
+type querier[T math32.VolumeType[E], E math32.Number] interface {
+	Reset()
+	Query(o T) T
+}
+
+// nearer gives the method of an iterator used by BatchNearest.
+type nearer[T math32.VolumeType[E], E math32.Number] interface {
+	Nearest(point math32.Coordinate[E]) (T, E)
+}
+
+// BatchQuery runs Query for each of the queries using up to workers goroutines, each with its own iterator. The
+// results for queries[i] are stored at index i, in the order the iterator returns them. If fn is not nil it is called
+// (from any goroutine) for each result, and only results for which it returns true are kept. The BVH must not be
+// modified until BatchQuery returns.
+func (b *BVol[T, E]) BatchQuery(queries []T, workers int, fn func(index int, found T) bool) [][]T {
+	return batchQuery(func() querier[T, E] { return b.Iterator() }, queries, workers, fn)
+}
+
+// BatchNearest runs Nearest for each of the points using up to workers goroutines. The nearest volume to points[i] and
+// its squared distance are stored at index i. The BVH must not be modified until BatchNearest returns.
+func (b *BVol[T, E]) BatchNearest(points []math32.Coordinate[E], workers int) ([]T, []E) {
+	return batchNearest(func() nearer[T, E] { return b.Iterator() }, points, workers)
+}
+
+// BatchQuery runs Query for each of the queries against the snapshot. See BVol.BatchQuery.
+func (s *Snapshot[T, E]) BatchQuery(queries []T, workers int, fn func(index int, found T) bool) [][]T {
+	return s.root.BatchQuery(queries, workers, fn)
+}
+
+// BatchNearest runs Nearest for each of the points against the snapshot. See BVol.BatchNearest.
+func (s *Snapshot[T, E]) BatchNearest(points []math32.Coordinate[E], workers int) ([]T, []E) {
+	return s.root.BatchNearest(points, workers)
+}
+
+// BatchQuery runs Query for each of the queries against the FlatBVH. See BVol.BatchQuery.
+func (f *FlatBVH[T, E]) BatchQuery(queries []T, workers int, fn func(index int, found T) bool) [][]T {
+	return batchQuery(func() querier[T, E] { return f.Iterator() }, queries, workers, fn)
+}
+
+// BatchNearest runs Nearest for each of the points against the FlatBVH. See BVol.BatchNearest.
+func (f *FlatBVH[T, E]) BatchNearest(points []math32.Coordinate[E], workers int) ([]T, []E) {
+	return batchNearest(func() nearer[T, E] { return f.Iterator() }, points, workers)
+}
+
+// BatchQuery runs Query for each of the queries against the BVH4. See BVol.BatchQuery.
+func (w *BVH4[T, E]) BatchQuery(queries []T, workers int, fn func(index int, found T) bool) [][]T {
+	return batchQuery(func() querier[T, E] { return w.Iterator() }, queries, workers, fn)
+}
+
+// BatchNearest runs Nearest for each of the points against the BVH4. See BVol.BatchNearest.
+func (w *BVH4[T, E]) BatchNearest(points []math32.Coordinate[E], workers int) ([]T, []E) {
+	return batchNearest(func() nearer[T, E] { return w.Iterator() }, points, workers)
+}
+
+func batchQuery[T math32.VolumeType[E], E math32.Number](iterator func() querier[T, E], queries []T, workers int,
+	fn func(index int, found T) bool) [][]T {
+	results := make([][]T, len(queries))
+	batch(len(queries), workers, iterator, func(iter querier[T, E], index int) {
+		o := queries[index]
+		iter.Reset()
+		for r := iter.Query(o); !r.IsNil(); r = iter.Query(o) {
+			if fn == nil || fn(index, r) {
+				results[index] = append(results[index], r)
+			}
+		}
+	})
+	return results
+}
+
+func batchNearest[T math32.VolumeType[E], E math32.Number](iterator func() nearer[T, E],
+	points []math32.Coordinate[E], workers int) ([]T, []E) {
+	nearest, distances := make([]T, len(points)), make([]E, len(points))
+	batch(len(points), workers, iterator, func(iter nearer[T, E], index int) {
+		nearest[index], distances[index] = iter.Nearest(points[index])
+	})
+	return nearest, distances
+}
+
+// batch calls run for every index below n using up to workers goroutines; the calling goroutine is the first worker.
+// Each goroutine creates one iterator and takes batchChunk indices at a time, so results do not depend on scheduling.
+func batch[I any](n, workers int, iterator func() I, run func(iter I, index int)) {
+	if chunks := (n + batchChunk - 1) / batchChunk; workers > chunks {
+		workers = chunks
+	}
+	var next atomic.Int64
+	work := func() {
+		iter := iterator()
+		for {
+			start := int(next.Add(int64(batchChunk))) - batchChunk
+			if start >= n {
+				return
+			}
+			for index := start; index < min(start+batchChunk, n); index++ {
+				run(iter, index)
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 1; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+}
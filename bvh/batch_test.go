@@ -0,0 +1,110 @@
+package collision
+
+import (
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestBatchQuery(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := TopDownBVH[*Orthotope[int32], int32](orths)
+	queries := randomOrths(500)
+	for _, q := range queries {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+	}
+
+	iter := tree.Iterator()
+	expected := make([][]*Orthotope[int32], len(queries))
+	for index, q := range queries {
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+			expected[index] = append(expected[index], r)
+		}
+	}
+
+	check := func(name string, results [][]*Orthotope[int32]) {
+		if len(results) != len(queries) {
+			t.Fatalf("%s returned %d results for %d queries", name, len(results), len(queries))
+		}
+		for index := range queries {
+			if len(results[index]) != len(expected[index]) {
+				t.Errorf("%s found %d volumes for query %d, expected %d", name, len(results[index]), index,
+					len(expected[index]))
+			}
+		}
+	}
+	for _, workers := range []int{0, 1, 3, 8} {
+		results := tree.BatchQuery(queries, workers, nil)
+		check("BVol", results)
+		// The BVol iterator returns results in the same order regardless of workers.
+		for index := range queries {
+			for r := range results[index] {
+				if results[index][r] != expected[index][r] {
+					t.Errorf("Query %d returned %v at %d, expected %v", index, results[index][r].String(), r,
+						expected[index][r].String())
+				}
+			}
+		}
+		check("FlatBVH", tree.Freeze().BatchQuery(queries, workers, nil))
+		check("BVH4", tree.Collapse().BatchQuery(queries, workers, nil))
+	}
+
+	// Only keep results that contain the corner of the query.
+	filtered := tree.BatchQuery(queries, 4, func(index int, found *Orthotope[int32]) bool {
+		return found.Contains(&Orthotope[int32]{Point: queries[index].Point})
+	})
+	for index := range queries {
+		for _, r := range filtered[index] {
+			if !r.Contains(&Orthotope[int32]{Point: queries[index].Point}) {
+				t.Errorf("Filtered results for query %d include %v", index, r.String())
+			}
+		}
+	}
+
+	if results := tree.BatchQuery(nil, 4, nil); len(results) != 0 {
+		t.Errorf("Expected no results for no queries, got %v", results)
+	}
+}
+
+func TestBatchNearest(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := NewPersistentBVol[*Orthotope[int32], int32]()
+	for _, orth := range orths {
+		tree.Add(orth)
+	}
+	snap := tree.Snapshot()
+	flat := snap.root.Freeze()
+
+	points := make([]Coordinate[int32], 300)
+	for index, q := range randomOrths(len(points)) {
+		points[index] = q.Point
+	}
+	nearest, distances := snap.BatchNearest(points, 4)
+	flatNearest, flatDistances := flat.BatchNearest(points, 3)
+	wideNearest, wideDistances := snap.root.Collapse().BatchNearest(points, 2)
+	iter := snap.Iterator()
+	for index, point := range points {
+		expected, distance := iter.Nearest(point)
+		if nearest[index] != expected || distances[index] != distance {
+			t.Errorf("Nearest to %v was %v, expected %v", point, nearest[index].String(), expected.String())
+		}
+		if flatDistances[index] != distance {
+			t.Errorf("Nearest to %v in the FlatBVH was %v at %d, expected %d", point, flatNearest[index].String(),
+				flatDistances[index], distance)
+		}
+		if wideDistances[index] != distance {
+			t.Errorf("Nearest to %v in the BVH4 was %v at %d, expected %d", point, wideNearest[index].String(),
+				wideDistances[index], distance)
+		}
+	}
+}
+
+func BenchmarkBatchQuery(b *testing.B) {
+	tree := TopDownBVH[*Orthotope[int32], int32](randomOrths(10000))
+	queries := randomOrths(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.BatchQuery(queries, 8, nil)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"image/color"
 	"image/png"
 	"os"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // BVol Bounding Volume for orthotopes. Wraps the orth and contains descendents.
@@ -16,12 +18,69 @@ type BVol[T math32.VolumeType[E], E math32.Number] struct {
 	vol   T
 	desc  [2]*BVol[T, E]
 	depth int32
+	gen   uint32
+	// count is the number of leaves at or below the volume.
+	count int32
+	// opt holds the layers, priority and aggregate of the volume, or is nil while they have their defaults.
+	opt *bvolOptions[T, E]
 }
 
-// minBound recalculates the minimum bounding volume based on children.
+// bvolOptions holds the data of optional features: layers, priorities and aggregates. Most BVHs use none of them, so
+// they are kept out of BVol, which every query walks.
+type bvolOptions[T math32.VolumeType[E], E math32.Number] struct {
+	// layers is the category bitmask of a leaf, or the union of the layers of the leaves below. shared is the
+	// intersection of the layers below, so that excluded branches may be skipped.
+	layers uint64
+	shared uint64
+	// priority of a leaf, or the maximum priority of the leaves below.
+	priority int32
+	// aug holds the aggregates of the leaves below, see Augment.
+	aug augment[T, E]
+}
+
+// options returns the options of the volume, creating them with the defaults if it has none.
+func (b *BVol[T, E]) options() *bvolOptions[T, E] {
+	if b.opt == nil {
+		layers := b.layers()
+		b.opt = &bvolOptions[T, E]{layers: layers, shared: layers}
+	}
+	return b.opt
+}
+
+// newLeaf creates a volume for orth in the given layers.
+func newLeaf[T math32.VolumeType[E], E math32.Number](orth T, layers uint64) *BVol[T, E] {
+	bvol := &BVol[T, E]{vol: orth, count: 1}
+	bvol.setLayers(layers, layers)
+	return bvol
+}
+
+// cloneVolume returns a copy of vol that copyVolume can restore it from, when a change to vol is rejected.
+func cloneVolume[T math32.VolumeType[E], E math32.Number](vol T) T {
+	clone := vol.New().(T)
+	copyVolume(clone, vol)
+	return clone
+}
+
+// copyVolume sets dst to a copy of src through math32.Copier, or else bounds src alone, so that any volume type can
+// be restored.
+func copyVolume[T math32.VolumeType[E], E math32.Number](dst, src T) {
+	if copier, ok := any(dst).(math32.Copier[E]); ok {
+		copier.CopyFrom(src)
+	} else {
+		dst.MinBounds(src)
+	}
+}
+
+// minBound recalculates the minimum bounding volume, layers, count, priority and aggregate based on children.
 func (b *BVol[T, E]) minBound() {
 	if b.depth > 0 {
-		b.vol.MinBounds(b.desc[0].vol, b.desc[1].vol)
+		minBoundsPair(b.vol, b.desc[0].vol, b.desc[1].vol)
+		b.count = b.desc[0].count + b.desc[1].count
+		b.relayer()
+		b.setPriority(max(b.desc[0].priority(), b.desc[1].priority()))
+		if aug := b.aug(); aug != nil {
+			aug.reaggregate(b)
+		}
 	}
 }
 
@@ -32,7 +91,7 @@ func (b *BVol[T, E]) redepth() {
 
 // byDimension provides functionality for the TopDownBVH algorithm
 type byDimension[T math32.VolumeType[E], E math32.Number] struct {
-	volumes   []math32.VolumeType[E]
+	volumes   []T
 	dimension int
 }
 
@@ -122,55 +181,97 @@ func (d byDimension[T, E]) Less(i, j int) bool {
 		}
 	}
 */
+// TopDownBVH creates a balanced BVH by recursively halving, sorting and comparing vols.
 func TopDownBVH[T math32.VolumeType[E], E math32.Number](orths []T) *BVol[T, E] {
-	if len(orths) == 1 {
-		return &BVol[T, E]{vol: orths[0]}
+	if len(orths) == 0 {
+		return &BVol[T, E]{}
 	}
+	sorted := make([]T, len(orths))
+	copy(sorted, orths)
+	return topDownBVH[T, E](sorted, nil)
+}
 
-	comp1 := orths[0].New().(T)
-	comp2 := orths[0].New().(T)
-	mid := len(orths) / 2
+// TopDownBVHParallel creates the same BVH as TopDownBVH, but builds large branches concurrently using up to workers
+// goroutines. Since workers beyond GOMAXPROCS could not run at once, at most GOMAXPROCS are used, and with one it is
+// TopDownBVH. The root is split before any other goroutine starts, which limits the speedup.
+func TopDownBVHParallel[T math32.VolumeType[E], E math32.Number](orths []T, workers int) *BVol[T, E] {
+	if workers = min(workers, runtime.GOMAXPROCS(0)); workers <= 1 || len(orths) < parallelThreshold {
+		return TopDownBVH[T, E](orths)
+	}
+	sorted := make([]T, len(orths))
+	copy(sorted, orths)
 
-	// Convert to interface slice for sorting
-	interfaceSlice := make([]math32.VolumeType[E], len(orths))
-	for i, v := range orths {
-		interfaceSlice[i] = v
+	// Each token allows one more goroutine; the calling goroutine is the first worker.
+	tokens := make(chan struct{}, workers-1)
+	for w := 1; w < workers; w++ {
+		tokens <- struct{}{}
 	}
+	return topDownBVH[T, E](sorted, tokens)
+}
+
+// parallelThreshold is the smallest number of volumes for which TopDownBVHParallel builds a branch concurrently.
+const parallelThreshold int = 1024
+
+// topDownBVH sorts orths in place. Branches are built in a new goroutine when a token can be taken from tokens.
+func topDownBVH[T math32.VolumeType[E], E math32.Number](orths []T, tokens chan struct{}) *BVol[T, E] {
+	if len(orths) == 1 {
+		return newLeaf[T, E](orths[0], DefaultLayer)
+	}
+	mid := len(orths) / 2
 
 	lowDim := 0
-	lowScore := math32.MAXVAL
+	var lowScore E
 
 	// Find best dimension to split
 	for d := 0; d < math32.DIMENSIONS; d++ {
-		sort.Sort(byDimension[T, E]{volumes: interfaceSlice, dimension: d})
+		sort.Sort(byDimension[T, E]{volumes: orths, dimension: d})
 
-		// Pass interface slice directly to MinBounds
-		comp1.MinBounds(interfaceSlice[:mid]...)
-		comp2.MinBounds(interfaceSlice[mid:]...)
+		var comp1, comp2 sahBin[E]
+		for _, orth := range orths[:mid] {
+			comp1.grow(orth.GetPoint(), orth.GetDelta())
+		}
+		for _, orth := range orths[mid:] {
+			comp2.grow(orth.GetPoint(), orth.GetDelta())
+		}
 
-		score := float32(comp1.Score() + comp2.Score())
-		if score < lowScore {
+		score := comp1.score() + comp2.score()
+		if d == 0 || score < lowScore {
 			lowScore = score
 			lowDim = d
 		}
 	}
 
 	// Final sort with best dimension
-	sort.Sort(byDimension[T, E]{volumes: interfaceSlice, dimension: lowDim})
+	if lowDim < math32.DIMENSIONS-1 {
+		sort.Sort(byDimension[T, E]{volumes: orths, dimension: lowDim})
+	}
 
-	// Create sorted concrete slice for recursion
-	sortedOrths := make([]T, len(interfaceSlice))
-	for i, v := range interfaceSlice {
-		sortedOrths[i] = v.(T)
+	var desc [2]*BVol[T, E]
+	spawn := false
+	if len(orths) >= parallelThreshold {
+		// Receiving from a nil channel never succeeds, so a serial build always takes the default.
+		select {
+		case <-tokens:
+			spawn = true
+		default:
+		}
 	}
 
-	return &BVol[T, E]{
-		vol: comp1,
-		desc: [2]*BVol[T, E]{
-			TopDownBVH(sortedOrths[:mid]),
-			TopDownBVH(sortedOrths[mid:]),
-		},
+	if spawn {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			desc[0] = topDownBVH[T, E](orths[:mid], tokens)
+			tokens <- struct{}{}
+		}()
+		desc[1] = topDownBVH[T, E](orths[mid:], tokens)
+		wg.Wait()
+	} else {
+		desc[0] = topDownBVH[T, E](orths[:mid], tokens)
+		desc[1] = topDownBVH[T, E](orths[mid:], tokens)
 	}
+	return joinBVol(desc[0], desc[1])
 }
 
 // Struggling with this gonna com back later
@@ -186,15 +287,44 @@ func (b *BVol[T, E]) Iterator() *orthStack[T, E] {
 	return stack
 }
 
+// stackPools holds a *sync.Pool of iterators for each type of orthStack, keyed by a nil *orthStack[T, E].
+var stackPools sync.Map
+
+// pooledIterator takes an iterator for b from the pool, keeping the stacks and scratch volume of an earlier
+// operation. Return it with releaseIterator.
+func (b *BVol[T, E]) pooledIterator() *orthStack[T, E] {
+	pool, ok := stackPools.Load((*orthStack[T, E])(nil))
+	if !ok {
+		pool, _ = stackPools.LoadOrStore((*orthStack[T, E])(nil), &sync.Pool{})
+	}
+	s, ok := pool.(*sync.Pool).Get().(*orthStack[T, E])
+	if !ok {
+		return b.Iterator()
+	}
+	s.bvh = b
+	s.Reset()
+	return s
+}
+
+// releaseIterator returns an iterator from pooledIterator to the pool, without holding on to the tree.
+func releaseIterator[T math32.VolumeType[E], E math32.Number](s *orthStack[T, E]) {
+	clear(s.bvStack[:cap(s.bvStack)])
+	s.bvh, s.bvStack = nil, s.bvStack[:0]
+	pool, _ := stackPools.Load((*orthStack[T, E])(nil))
+	pool.(*sync.Pool).Put(s)
+}
+
 // Add an orth to a Bounding Volume Hierarchy. Only add to root volume.
 func (b *BVol[T, E]) Add(orth T) bool {
-	s := b.Iterator()
+	s := b.pooledIterator()
+	defer releaseIterator(s)
 	return s.Add(orth)
 }
 
 // Remove an orth from a Bounding Volume Hierarchy. Only remove from the root volume.
 func (b *BVol[T, E]) Remove(orth T) bool {
-	s := b.Iterator()
+	s := b.pooledIterator()
+	defer releaseIterator(s)
 	return s.Remove(orth)
 }
 
@@ -216,11 +346,55 @@ func (b *BVol[T, E]) redistribute() {
 	b.redepth()
 }
 
+// balanceBVol restores the depth and bounds of bvol, whose children must already be balanced. While one child is
+// deeper than the other by two or more, its deeper child is swapped with the other, which is then balanced in turn.
+// Since the order of children does not matter, this needs no double rotations. Finally redistribute looks for a better
+// split. own is called on each internal child before it may be modified (see Txn), or is nil.
+func balanceBVol[T math32.VolumeType[E], E math32.Number](bvol *BVol[T, E], own func(*BVol[T, E]) *BVol[T, E]) {
+	for {
+		// Rotations and redistribute modify the children of bvol.
+		for index, child := range bvol.desc {
+			if own != nil && child.depth > 0 {
+				bvol.desc[index] = own(child)
+			}
+		}
+		deep := 0
+		if bvol.desc[1].depth > bvol.desc[0].depth {
+			deep = 1
+		}
+		child, other := bvol.desc[deep], bvol.desc[deep^1]
+		if child.depth-other.depth < 2 {
+			break
+		}
+		gIndex := 0
+		if child.desc[1].depth > child.desc[0].depth {
+			gIndex = 1
+		}
+		bvol.desc[deep^1], child.desc[gIndex] = child.desc[gIndex], other
+		balanceBVol(child, own)
+	}
+	bvol.redepth()
+	bvol.minBound()
+	bvol.redistribute()
+	bvol.minBound()
+}
+
+// rebalance balances every volume below b, children first, such as after a bulk builder split unevenly. The depths
+// of any two siblings then differ by at most one, as Add and Remove expect.
+func (b *BVol[T, E]) rebalance() {
+	if b.depth == 0 {
+		return
+	}
+	b.desc[0].rebalance()
+	b.desc[1].rebalance()
+	balanceBVol(b, nil)
+}
+
 // swapCheck checks for a more optimal balance for the descends and swaps if it finds one.
 func swapCheck[T math32.VolumeType[E], E math32.Number](first *BVol[T, E], second *BVol[T, E], secIndex int) {
 	first.minBound()
 	second.minBound()
-	minScore := first.vol.Score() + second.vol.Score()
+	minScore := math32.AddSat(first.vol.Score(), second.vol.Score())
 	minIndex := -1
 
 	for index := 0; index < 2; index++ {
@@ -232,7 +406,7 @@ func swapCheck[T math32.VolumeType[E], E math32.Number](first *BVol[T, E], secon
 			// Score first then second, since first may be a child of second.
 			first.minBound()
 			second.minBound()
-			score := first.vol.Score() + second.vol.Score()
+			score := math32.AddSat(first.vol.Score(), second.vol.Score())
 			if score < minScore {
 				// Update the children with the best split
 				minScore = score
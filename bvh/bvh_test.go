@@ -4,6 +4,8 @@ import (
 	"github.com/briannoyama/bvh/math32"
 	. "github.com/briannoyama/bvh/math32"
 
+	"math/rand"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -15,6 +17,53 @@ func TestTopDownBVH(t *testing.T) {
 	if tree.Score() > 262 {
 		t.Errorf("Inefficient BVH created via TopDown:\n%v", tree.String())
 	}
+	checkBounds(t, tree)
+}
+
+func TestTopDownBVHParallel(t *testing.T) {
+	// Allow the workers to run at once, even with a single CPU.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	orths := randomOrths(5000)
+	serial := TopDownBVH[*Orthotope[int32], int32](orths)
+	for _, workers := range []int{0, 1, 2, 8} {
+		tree := TopDownBVHParallel[*Orthotope[int32], int32](orths, workers)
+		if !tree.Equals(serial) {
+			t.Errorf("Parallel BVH with %d workers differs from the serial BVH.", workers)
+		}
+		if tree.GetDepth() != serial.GetDepth() {
+			t.Errorf("Unexpected depth: %d\nExpected: %d\n", tree.GetDepth(), serial.GetDepth())
+		}
+	}
+	checkBounds(t, serial)
+}
+
+func BenchmarkTopDownBVH(b *testing.B) {
+	orths := randomOrths(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TopDownBVH[*Orthotope[int32], int32](orths)
+	}
+}
+
+func BenchmarkTopDownBVHParallel(b *testing.B) {
+	orths := randomOrths(20000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TopDownBVHParallel[*Orthotope[int32], int32](orths, runtime.NumCPU())
+	}
+}
+
+// randomOrths creates reproducible cubes within a 1000 unit space.
+func randomOrths(n int) []*Orthotope[int32] {
+	r := rand.New(rand.NewSource(7))
+	orths := make([]*Orthotope[int32], n)
+	for i := range orths {
+		orths[i] = &Orthotope[int32]{
+			Point: Coordinate[int32]{r.Int31n(1000), r.Int31n(1000), r.Int31n(1000)},
+			Delta: Coordinate[int32]{r.Int31n(20), r.Int31n(20), r.Int31n(20)},
+		}
+	}
+	return orths
 }
 
 func TestAdd(t *testing.T) {
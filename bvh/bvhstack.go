@@ -14,6 +14,8 @@ type OrthStack[T math32.VolumeType[E], E math32.Number] interface {
 	Add(orth math32.VolumeType[E]) bool
 	Contains(orth math32.VolumeType[E]) bool
 	Remove(o math32.VolumeType[E]) bool
+	Insert(orth math32.VolumeType[E]) error
+	Delete(o math32.VolumeType[E]) error
 }
 
 // orthStack provides memory efficient stack based methods for manipulating BVHs.
@@ -21,6 +23,9 @@ type orthStack[T math32.VolumeType[E], E math32.Number] struct {
 	bvh      *BVol[T, E]
 	bvStack  []*BVol[T, E]
 	intStack []int32
+	arena    *Arena[T, E]
+	scratch  T
+	cow      *cowState
 }
 
 // Reset the stack to its initial state (see BVol.Iterator).
@@ -85,7 +90,7 @@ func (s *orthStack[T, E]) traceUp() bool {
 	return true
 }
 
-func (s *orthStack[T, E]) queryNext(o T) *BVol[T, E] {
+func (s *orthStack[T, E]) queryNext(o T, include, exclude uint64) *BVol[T, E] {
 	bvol, index := s.peek()
 	for bvol.depth > 0 {
 		if index >= 2 {
@@ -93,7 +98,7 @@ func (s *orthStack[T, E]) queryNext(o T) *BVol[T, E] {
 				break
 			}
 		} else {
-			if bvol.desc[index].vol.Overlaps(o) {
+			if bvol.desc[index].inLayers(include, exclude) && bvol.desc[index].vol.Overlaps(o) {
 				s.append(bvol.desc[index], 0)
 			} else {
 				s.intStack[len(s.intStack)-1]++
@@ -105,18 +110,22 @@ func (s *orthStack[T, E]) queryNext(o T) *BVol[T, E] {
 }
 
 // Duplicate of queryNext using "Instersects" instead for higher performance.
-func (s *orthStack[T, E]) intersectsNext(orth T, delta *math32.Coordinate[E]) (*BVol[T, E], E) {
+func (s *orthStack[T, E]) intersectsNext(orth T, delta *math32.Coordinate[E],
+	include, exclude uint64) (*BVol[T, E], E) {
 	bvol, index := s.peek()
 	var distance E = -1
+	one := math32.Unit[E]()
 	for bvol.depth > 0 {
 		if index >= 2 {
 			if !s.traceUp() {
 				break
 			}
+		} else if !bvol.desc[index].inLayers(include, exclude) {
+			s.intStack[len(s.intStack)-1]++
 		} else {
 			distance = bvol.desc[index].vol.Intersects(orth, delta)
 			// If distance is between 0 and 1
-			if distance >= 0 && distance <= 1 {
+			if distance >= 0 && distance <= one {
 				s.append(bvol.desc[index], 0)
 			} else {
 				s.intStack[len(s.intStack)-1]++
@@ -130,13 +139,21 @@ func (s *orthStack[T, E]) intersectsNext(orth T, delta *math32.Coordinate[E]) (*
 // Query looks for intersections between the orth, o, and the BVH
 // returning one intersection at a time.
 func (s *orthStack[T, E]) Query(o T) T {
+	return s.QueryLayers(o, AllLayers, 0)
+}
+
+// QueryLayers looks for intersections between the orth, o, and the volumes of the BVH in any of the include layers
+// and none of the exclude layers, returning one intersection at a time. Branches without such volumes are skipped.
+func (s *orthStack[T, E]) QueryLayers(o T, include, exclude uint64) T {
 	// When the stack is empty, there are no more volumes to return.
 	if !s.HasNext() {
 		var zero T
 		return zero
 	}
-	bvol := s.queryNext(o)
-	if !s.HasNext() {
+	bvol := s.queryNext(o, include, exclude)
+	if !s.HasNext() || !bvol.inLayers(include, exclude) {
+		s.bvStack = s.bvStack[:0]
+		s.intStack = s.intStack[:0]
 		var zero T
 		return zero
 	}
@@ -149,13 +166,21 @@ func (s *orthStack[T, E]) Query(o T) T {
 // Intersects traces the path of a moving orth through the BVH returning an orth and the distance from the
 // source orth's origin along it's delta. It does not guarantee order.
 func (s *orthStack[T, E]) Intersects(orth T, delta *math32.Coordinate[E]) (T, E) {
+	return s.IntersectsLayers(orth, delta, AllLayers, 0)
+}
+
+// IntersectsLayers traces the path of a moving orth like Intersects, only returning volumes in any of the include
+// layers and none of the exclude layers. Branches without such volumes are skipped.
+func (s *orthStack[T, E]) IntersectsLayers(orth T, delta *math32.Coordinate[E], include, exclude uint64) (T, E) {
 	var zero T
 	var zeroE E
 	if !s.HasNext() {
 		return zero, zeroE - 1 // Handle according to E's type
 	}
-	bvol, distance := s.intersectsNext(orth, delta)
-	if !s.HasNext() {
+	bvol, distance := s.intersectsNext(orth, delta, include, exclude)
+	if !s.HasNext() || !bvol.inLayers(include, exclude) {
+		s.bvStack = s.bvStack[:0]
+		s.intStack = s.intStack[:0]
 		return zero, zeroE - 1
 	}
 
@@ -164,9 +189,81 @@ func (s *orthStack[T, E]) Intersects(orth T, delta *math32.Coordinate[E]) (T, E)
 	return bvol.vol, distance
 }
 
+// Nearest returns the volume closest to point and the squared distance to its bounds (see GetPoint and GetDelta), or
+// -1 when the BVH is empty. The stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) Nearest(point math32.Coordinate[E]) (T, E) {
+	var best T
+	var bestDist E = -1
+	s.Reset()
+	if s.bvh.vol.IsNil() {
+		s.pop()
+		return best, bestDist
+	}
+
+	mul := math32.MulFunc[E]()
+	for s.HasNext() {
+		bvol, _ := s.pop()
+		dist := volDistanceSq(point, bvol.vol, mul)
+		if bestDist >= 0 && dist >= bestDist {
+			continue
+		}
+		if bvol.depth == 0 {
+			best, bestDist = bvol.vol, dist
+			continue
+		}
+
+		// Visit the closer child first by pushing it last.
+		first, second := bvol.desc[0], bvol.desc[1]
+		if volDistanceSq(point, second.vol, mul) > volDistanceSq(point, first.vol, mul) {
+			first, second = second, first
+		}
+		s.append(first, 0)
+		s.append(second, 0)
+	}
+	return best, bestDist
+}
+
+// Walk visits the volumes of the BVH in pre-order, for writing custom queries. The descendants of a volume are skipped
+// when nodeTest returns false for its bounds and depth (the height above the leaves, so 0 for a leaf). Leaves that pass
+// nodeTest are given to leafFn, which returns false to end the walk. The stack is emptied; call Reset before further
+// queries.
+func (s *orthStack[T, E]) Walk(nodeTest func(bounds T, depth int32) bool, leafFn func(T) bool) {
+	s.Reset()
+	if s.bvh.vol.IsNil() {
+		s.pop()
+		return
+	}
+
+	for s.HasNext() {
+		bvol, _ := s.pop()
+		if !nodeTest(bvol.vol, bvol.depth) {
+			continue
+		}
+		if bvol.depth == 0 {
+			if !leafFn(bvol.vol) {
+				s.bvStack = s.bvStack[:0]
+				s.intStack = s.intStack[:0]
+				return
+			}
+			continue
+		}
+		// Push the second child first so that the first is visited first.
+		s.append(bvol.desc[1], 0)
+		s.append(bvol.desc[0], 0)
+	}
+}
+
+// volDistanceSq returns the squared distance from point to the bounds of vol. mul is from math32.MulFunc.
+func volDistanceSq[T math32.VolumeType[E], E math32.Number](point math32.Coordinate[E], vol T, mul func(a, b E) E) E {
+	min, delta := vol.GetPoint(), vol.GetDelta()
+	return distanceSq(point, min, min.Add(delta), mul)
+}
+
+// path moves the stack to a leaf whose volume equals o, descending only into volumes that contain it. Internal
+// volumes whose bounds equal o do not match.
 func (s *orthStack[T, E]) path(o T) *BVol[T, E] {
 	bvol, index := s.peek()
-	for !bvol.vol.Equals(o) && s.HasNext() {
+	for (bvol.depth > 0 || !bvol.vol.Equals(o)) && s.HasNext() {
 		if bvol.depth == 0 {
 			if !s.traceUp() {
 				break
@@ -205,10 +302,16 @@ func (s *orthStack[T, E]) Contains(o T) bool {
 	return false
 }
 
-// Add an orth to a Bounding Volume Hierarchy. Only add to root volume.
+// Add an orth to a Bounding Volume Hierarchy in the DefaultLayer. Only add to root volume.
 func (s *orthStack[T, E]) Add(orth T) bool {
+	return s.AddLayers(orth, DefaultLayer)
+}
 
-	if s.Contains(orth) {
+// AddLayers adds an orth to a Bounding Volume Hierarchy in the given layers (a category bitmask, see QueryLayers).
+// Returns false if it was already added, or is invalid (see math32.Validate), since NaN or overflowing coordinates
+// would corrupt the bounds of every ancestor.
+func (s *orthStack[T, E]) AddLayers(orth T, layers uint64) bool {
+	if orth.IsNil() || math32.Validate[E](orth) != nil || s.Contains(orth) {
 		return false
 	}
 
@@ -216,32 +319,60 @@ func (s *orthStack[T, E]) Add(orth T) bool {
 	bvol := s.bvh
 	if bvol.vol.IsNil() {
 		// Add by setting the vol when there is no volumes.
-		bvol.vol = orth
+		s.own()
+		s.bvh.vol = orth
+		s.bvh.count = 1
+		s.bvh.setLayers(layers, layers)
+		s.bvh.setPriority(0)
+		if aug := s.bvh.aug(); aug != nil {
+			aug.reaggregate(s.bvh)
+		}
 		return true
 	}
 	lowIndex := int32(-1)
 
-	for next := bvol; !next.vol.Equals(orth); next = next.desc[lowIndex] {
+	for next := bvol; ; next = next.desc[lowIndex] {
 		if next.depth == 0 {
 			// We've reached a leaf node, and we need to insert a parent node.
 			if next.vol.IsSame(orth) {
 				return false
 			}
+			if s.cow != nil {
+				// Copy the path before modifying it.
+				s.append(next, 0)
+				s.own()
+				next, _ = s.pop()
+			}
 
-			next.desc[0] = &BVol[T, E]{vol: orth}
-			next.desc[1] = &BVol[T, E]{vol: next.vol}
+			next.desc[0] = s.newNode(orth, layers)
+			next.desc[1] = s.newNode(next.vol, next.layers())
+			next.desc[1].setPriority(next.priority())
+			next.count = 2
+			next.setLayers(next.layers()|layers, next.shared()&layers)
+			next.setPriority(max(next.priority(), 0))
 			next.depth = 1
-			comp := orth.New().(T)
-			comp.MinBounds(orth, next.vol)
+			comp := s.newVol(orth)
+			minBoundsPair(comp, orth, next.vol)
 			next.vol = comp
-			lowIndex = int32(0)
+			if aug := next.aug(); aug != nil {
+				// The previous leaf keeps its aggregate.
+				next.desc[1].setAug(aug)
+				next.setAug(aug.clone())
+				next.desc[0].setAug(aug.clone())
+				next.desc[0].aug().reaggregate(next.desc[0])
+				next.aug().reaggregate(next)
+			}
+			s.append(next, 0)
+			break
 		} else {
 			// We cannot add the orth here. Descend.
 			smallestScore := math32.MaxValue[E]()
+			if s.scratch.IsNil() {
+				s.scratch = orth.New().(T)
+			}
 			for index := range next.desc {
-				temp := next.desc[index].vol.New().(T)
-				temp.MinBounds(orth, next.desc[index].vol)
-				score := temp.Score() - next.desc[index].vol.Score()
+				minBoundsPair(s.scratch, orth, next.desc[index].vol)
+				score := s.scratch.Score() - next.desc[index].vol.Score()
 
 				if score < smallestScore {
 					lowIndex = int32(index)
@@ -262,9 +393,11 @@ func (s *orthStack[T, E]) Add(orth T) bool {
 func (s *orthStack[T, E]) Remove(o T) bool {
 	s.Reset()
 	bvol := s.path(o)
-	if bvol == nil || !bvol.vol.Equals(o) {
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
 		return false
 	}
+	s.own()
+	bvol, _ = s.peek()
 
 	s.pop()
 	if s.HasNext() {
@@ -273,24 +406,44 @@ func (s *orthStack[T, E]) Remove(o T) bool {
 			gParent, gIndex := s.peek()
 			if gIndex < 2 && gParent != nil {
 				gParent.desc[gIndex] = parent.desc[pIndex^1]
+				s.release(bvol)
+				s.release(parent)
 				s.rebalanceRemove()
 			}
 		} else if parent != nil {
 			cousin := parent.desc[pIndex^1]
 			if cousin != nil {
+				s.recycle(parent.vol)
 				parent.vol = cousin.vol
 				parent.desc = cousin.desc
 				parent.depth = cousin.depth
+				parent.count, parent.opt = cousin.count, cousin.opt
+				// The cousin's volume now belongs to the parent.
+				*cousin = BVol[T, E]{}
+				s.release(cousin)
+				s.release(bvol)
 			} else {
 				parent.vol = *new(T)
 				parent.desc = [2]*BVol[T, E]{}
 				parent.depth = 0
+				parent.count = 0
+				parent.setLayers(0, 0)
+				parent.setPriority(0)
+				if aug := parent.aug(); aug != nil {
+					aug.reaggregate(parent)
+				}
 			}
 		}
 	} else if bvol != nil {
 		bvol.vol = *new(T)
 		bvol.desc = [2]*BVol[T, E]{}
 		bvol.depth = 0
+		bvol.count = 0
+		bvol.setLayers(0, 0)
+		bvol.setPriority(0)
+		if aug := bvol.aug(); aug != nil {
+			aug.reaggregate(bvol)
+		}
 	}
 	return true
 }
@@ -301,7 +454,7 @@ func (s *orthStack[T, E]) Score() E {
 	var score E
 
 	for s.HasNext() {
-		score += s.Next().vol.Score()
+		score = math32.AddSat(score, s.Next().vol.Score())
 	}
 	return score
 }
@@ -342,9 +495,9 @@ func (s *orthStack[T, E]) rebalanceRemove() {
 			// Swap to fix balance. Try to minimize hierarchy with swap.
 			if cousin.desc[1].depth == depth+1 {
 				if cousin.desc[0].depth == depth+1 {
-					cousin.vol.MinBounds(cousin.desc[1].vol, parent.desc[pIndex].vol)
+					minBoundsPair(cousin.vol, cousin.desc[1].vol, parent.desc[pIndex].vol)
 					score := cousin.vol.Score() - cousin.desc[1].vol.Score()
-					cousin.vol.MinBounds(cousin.desc[0].vol, parent.desc[pIndex].vol)
+					minBoundsPair(cousin.vol, cousin.desc[0].vol, parent.desc[pIndex].vol)
 					if score < cousin.vol.Score()-cousin.desc[0].vol.Score() {
 						swap = 1
 					}
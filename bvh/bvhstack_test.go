@@ -1,6 +1,7 @@
 package collision
 
 import (
+	"math"
 	"testing"
 
 	"github.com/briannoyama/bvh/math32"
@@ -175,3 +176,129 @@ func TestBVHContains(t *testing.T) {
 		}
 	}
 }
+
+func TestWalk(t *testing.T) {
+	tree := getIdealTree()
+	iter := tree.Iterator()
+
+	// Walking every volume visits the leaves in the same order as Next.
+	var leaves []*Orthotope[float32]
+	for iter.HasNext() {
+		if next := iter.Next(); next.depth == 0 {
+			leaves = append(leaves, next.vol)
+		}
+	}
+	visited := 0
+	iter.Walk(func(*Orthotope[float32], int32) bool {
+		visited++
+		return true
+	}, func(orth *Orthotope[float32]) bool {
+		if orth != leaves[0] {
+			t.Errorf("Walk returned %v, expected %v", orth.String(), leaves[0].String())
+		}
+		leaves = leaves[1:]
+		return true
+	})
+	if len(leaves) != 0 || visited != 2*len(leaf)-1 || iter.HasNext() {
+		t.Errorf("Walk did not visit every volume: %d remain after %d visits", len(leaves), visited)
+	}
+
+	// A custom query for volumes within a distance of a point.
+	point := Coordinate[float32]{14, 11}
+	found := map[*Orthotope[float32]]bool{}
+	visited = 0
+	iter.Walk(func(bounds *Orthotope[float32], depth int32) bool {
+		visited++
+		return volDistanceSq(point, bounds, nil) <= 4
+	}, func(orth *Orthotope[float32]) bool {
+		found[orth] = true
+		return true
+	})
+	for _, orth := range leaf {
+		if found[orth] != (volDistanceSq(point, orth, nil) <= 4) {
+			t.Errorf("Unexpected result for %v within 2 of %v", orth.String(), point)
+		}
+	}
+	if visited >= 2*len(leaf)-1 {
+		t.Errorf("Walk did not prune any volumes")
+	}
+
+	// Stop after the first leaf.
+	count := 0
+	iter.Walk(func(*Orthotope[float32], int32) bool { return true }, func(*Orthotope[float32]) bool {
+		count++
+		return false
+	})
+	if count != 1 || iter.HasNext() {
+		t.Errorf("Walk continued after leafFn returned false")
+	}
+
+	empty := (&BVol[*math32.Orthotope[float32], float32]{}).Iterator()
+	empty.Walk(func(*Orthotope[float32], int32) bool { return true }, func(orth *Orthotope[float32]) bool {
+		t.Errorf("Walking an empty hierarchy returned %v", orth)
+		return true
+	})
+}
+
+func TestExtremeCoordinates(t *testing.T) {
+	limit := MaxCoordinate[int32]()
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := tree.Iterator()
+	var orths []*Orthotope[int32]
+	for i := int32(0); i < 64; i++ {
+		// Spread boxes of up to a quarter of the range over the corners of the valid coordinates.
+		point := Coordinate[int32]{-limit, -limit, -limit}
+		delta := Coordinate[int32]{i * (limit / 128), limit / 2, 0}
+		for dim := 0; dim < DIMENSIONS; dim++ {
+			if i>>dim&1 == 1 {
+				point[dim] = limit - delta[dim]
+			}
+		}
+		orth, err := NewOrthotope(point, delta)
+		if err != nil {
+			t.Fatalf("Unable to create an orthotope at %v with delta %v: %v", point, delta, err)
+		}
+		orths = append(orths, orth)
+		iter.Add(orth)
+	}
+
+	if err := tree.vol.Validate(); err != nil || tree.GetDepth() > 7 {
+		t.Errorf("Unexpected bounds %v with depth %d: %v", tree.vol, tree.GetDepth(), err)
+	}
+	for _, orth := range orths {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find %v", orth)
+		}
+	}
+	iter.Reset()
+	for iter.HasNext() {
+		if next := iter.Next(); next.depth > 0 && (!next.vol.Contains(next.desc[0].vol) ||
+			!next.vol.Contains(next.desc[1].vol)) {
+			t.Errorf("Bounds %v do not contain their children", next.vol)
+		}
+	}
+	if score := iter.Score(); score != math.MaxInt32 {
+		t.Errorf("Expected the score to saturate, got %d", score)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	tree := getIdealTree()
+	iter := tree.Iterator()
+	bounds := *tree.vol
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	for _, orth := range []*Orthotope[float32]{
+		nil,
+		{Point: Coordinate[float32]{nan, 3}, Delta: Coordinate[float32]{1, 1}},
+		{Point: Coordinate[float32]{3, 3}, Delta: Coordinate[float32]{inf, 1}},
+		{Point: Coordinate[float32]{3, -inf}, Delta: Coordinate[float32]{1, 1}},
+		{Point: Coordinate[float32]{3, 3}, Delta: Coordinate[float32]{1, -1}},
+	} {
+		if iter.Add(orth) || iter.Contains(orth) {
+			t.Errorf("Added an invalid volume: %v", orth)
+		}
+	}
+	if !tree.vol.Equals(&bounds) {
+		t.Errorf("Invalid volumes changed the bounds to %v", tree.vol.String())
+	}
+}
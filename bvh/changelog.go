@@ -0,0 +1,393 @@
+package collision
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// logVersion is the first byte of encoded changes and checkpoints.
+const logVersion byte = 1
+
+// Op is the kind of a Change.
+type Op byte
+
+const (
+	// OpAdd adds a volume with a new ID.
+	OpAdd Op = iota + 1
+	// OpRemove removes the volume with the ID.
+	OpRemove
+	// OpUpdate replaces the bounds of the volume with the ID, keeping its layers.
+	OpUpdate
+)
+
+// Change is an entry of a change log. Vol is nil for OpRemove, and Layers is only set for OpAdd.
+type Change[T math32.VolumeType[E], E math32.Number] struct {
+	Seq    uint64
+	Op     Op
+	ID     uint64
+	Layers uint64
+	Vol    T
+}
+
+// logVolume is a volume that may be used as a map key, so that IDs follow the exact instance.
+type logVolume[E math32.Number] interface {
+	math32.VolumeType[E]
+	comparable
+}
+
+// LoggedBVol is a BVH that records an ordered log of its additions, removals and updates, so that it may be mirrored
+// by Replicas, such as on the clients of a server. Each change has the next sequence number, starting from 1, and each
+// volume an ID that replicas use to find it. Every interval changes the LoggedBVol takes a checkpoint of all of its
+// volumes, and drops the changes before the previous checkpoint; replicas that fall further behind (or join late)
+// restore the checkpoint. A LoggedBVol is not thread-safe.
+type LoggedBVol[T logVolume[E], E math32.Number] struct {
+	bvh   BVol[T, E]
+	iter  *orthStack[T, E]
+	codec Codec[T, E]
+	ids   map[T]uint64
+	next  uint64
+
+	seq      uint64
+	interval int
+	// log holds the encoded changes after the previous checkpoint, and offsets the start of each of them.
+	log        []byte
+	offsets    []int
+	checkpoint []byte
+	// since is the number of changes after the latest checkpoint.
+	since int
+}
+
+// NewLoggedBVol creates an empty LoggedBVol that encodes volumes with codec, and takes a checkpoint every interval
+// changes. An interval of 0 keeps every change instead.
+func NewLoggedBVol[T logVolume[E], E math32.Number](codec Codec[T, E], interval int) *LoggedBVol[T, E] {
+	l := &LoggedBVol[T, E]{codec: codec, ids: map[T]uint64{}, interval: interval}
+	l.iter = l.bvh.Iterator()
+	l.checkpoint = l.encodeCheckpoint()
+	return l
+}
+
+// Add an orth to the BVH in the DefaultLayer. Returns the errors of Insert.
+func (l *LoggedBVol[T, E]) Add(orth T) error {
+	return l.AddLayers(orth, DefaultLayer)
+}
+
+// AddLayers adds an orth to the BVH in the given layers. Returns the errors of Insert.
+func (l *LoggedBVol[T, E]) AddLayers(orth T, layers uint64) error {
+	if err := validVolume(orth); err != nil {
+		return err
+	}
+	if _, ok := l.ids[orth]; ok || !l.iter.AddLayers(orth, layers) {
+		return ErrDuplicate
+	}
+	l.next++
+	l.ids[orth] = l.next
+	l.record(Change[T, E]{Op: OpAdd, ID: l.next, Layers: layers, Vol: orth})
+	return nil
+}
+
+// Remove an orth from the BVH. Returns ErrNotFound if it was not added.
+func (l *LoggedBVol[T, E]) Remove(orth T) error {
+	id, ok := l.ids[orth]
+	if !ok || !l.iter.Remove(orth) {
+		return ErrNotFound
+	}
+	delete(l.ids, orth)
+	l.record(Change[T, E]{Op: OpRemove, ID: id})
+	return nil
+}
+
+// Update removes the orth, lets move modify it, then adds it back in the same layers. Returns ErrNotFound (without
+// calling move) if the orth was not added. If move leaves the orth invalid, ErrInvalidVolume is returned, the orth is
+// restored to its previous bounds and no change is recorded.
+func (l *LoggedBVol[T, E]) Update(orth T, move func(T)) error {
+	id, ok := l.ids[orth]
+	if !ok {
+		return ErrNotFound
+	}
+	layers := l.iter.Layers(orth)
+	if !l.iter.Remove(orth) {
+		return ErrNotFound
+	}
+	saved := cloneVolume(orth)
+	move(orth)
+	err := validVolume(orth)
+	if err == nil && !l.iter.AddLayers(orth, layers) {
+		err = ErrDuplicate
+	}
+	if err != nil {
+		copyVolume(orth, saved)
+		l.iter.AddLayers(orth, layers)
+		return err
+	}
+	l.record(Change[T, E]{Op: OpUpdate, ID: id, Vol: orth})
+	return nil
+}
+
+// ID returns the ID of an orth in the BVH, or 0 if it was not added.
+func (l *LoggedBVol[T, E]) ID(orth T) uint64 {
+	return l.ids[orth]
+}
+
+// Seq returns the sequence number of the latest change, or 0 if there are none.
+func (l *LoggedBVol[T, E]) Seq() uint64 {
+	return l.seq
+}
+
+// Iterator for the current state of the BVH. It is invalidated by changes.
+func (l *LoggedBVol[T, E]) Iterator() Reader[T, E] {
+	return l.bvh.Iterator()
+}
+
+// Len returns the number of volumes stored in the BVH.
+func (l *LoggedBVol[T, E]) Len() int {
+	return l.bvh.Len()
+}
+
+// Changes returns the encoded changes after the sequence number since, for Replica.Apply. Returns ErrLogTruncated if
+// some of them were dropped by a checkpoint; restore the Checkpoint instead.
+func (l *LoggedBVol[T, E]) Changes(since uint64) ([]byte, error) {
+	first := l.seq - uint64(len(l.offsets)) + 1
+	if since+1 < first {
+		return nil, fmt.Errorf("%w: changes before %d were dropped", ErrLogTruncated, first)
+	}
+	buf := binary.AppendUvarint([]byte{logVersion}, since+1)
+	if since < l.seq {
+		buf = append(buf, l.log[l.offsets[since+1-first]:]...)
+	}
+	return buf, nil
+}
+
+// Checkpoint returns the encoded state of the BVH as of the latest checkpoint, for Replica.Restore. The changes after
+// it, and those after the previous checkpoint, are available from Changes.
+func (l *LoggedBVol[T, E]) Checkpoint() []byte {
+	return l.checkpoint
+}
+
+// record encodes a change with the next sequence number, then takes a checkpoint if the interval has passed.
+func (l *LoggedBVol[T, E]) record(change Change[T, E]) {
+	l.seq++
+	l.offsets = append(l.offsets, len(l.log))
+	l.log = append(l.log, byte(change.Op))
+	l.log = binary.AppendUvarint(l.log, change.ID)
+	if change.Op == OpAdd {
+		l.log = binary.AppendUvarint(l.log, change.Layers)
+	}
+	if change.Op != OpRemove {
+		l.log = l.codec.AppendVolume(l.log, change.Vol)
+	}
+
+	if l.since++; l.interval > 0 && l.since >= l.interval {
+		l.checkpoint = l.encodeCheckpoint()
+		if drop := len(l.offsets) - l.since; drop > 0 {
+			base := l.offsets[drop]
+			l.log = append(l.log[:0], l.log[base:]...)
+			l.offsets = append(l.offsets[:0], l.offsets[drop:]...)
+			for i := range l.offsets {
+				l.offsets[i] -= base
+			}
+		}
+		l.since = 0
+	}
+}
+
+// encodeCheckpoint encodes the sequence number and then the ID, layers and volume of each leaf.
+func (l *LoggedBVol[T, E]) encodeCheckpoint() []byte {
+	buf := binary.AppendUvarint([]byte{logVersion}, l.seq)
+	buf = binary.AppendUvarint(buf, uint64(l.bvh.Len()))
+	iter := l.bvh.Iterator()
+	for iter.HasNext() {
+		bvol := iter.Next()
+		if bvol.depth > 0 || bvol.vol.IsNil() {
+			continue
+		}
+		buf = binary.AppendUvarint(buf, l.ids[bvol.vol])
+		buf = binary.AppendUvarint(buf, bvol.layers())
+		buf = l.codec.AppendVolume(buf, bvol.vol)
+	}
+	return buf
+}
+
+// Replica mirrors a LoggedBVol by applying its changes. A Replica is not thread-safe.
+type Replica[T math32.VolumeType[E], E math32.Number] struct {
+	bvh   BVol[T, E]
+	codec Codec[T, E]
+	vols  map[uint64]T
+	seq   uint64
+}
+
+// NewReplica creates an empty Replica that decodes volumes with codec.
+func NewReplica[T math32.VolumeType[E], E math32.Number](codec Codec[T, E]) *Replica[T, E] {
+	return &Replica[T, E]{codec: codec, vols: map[uint64]T{}}
+}
+
+// Apply the encoded changes from LoggedBVol.Changes. Changes that were already applied are skipped, so the same
+// changes may be received twice. They are applied in one transaction (see BVol.Begin), so if any of them fail the
+// replica is left as it was. Returns ErrSequenceGap if changes are missing before data, ErrCorrupt if data could not
+// be decoded, or the error of the change that failed.
+func (r *Replica[T, E]) Apply(data []byte) error {
+	changes, err := DecodeChanges(r.codec, data)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 && changes[0].Seq > r.seq+1 {
+		return fmt.Errorf("%w: expected %d, got %d", ErrSequenceGap, r.seq+1, changes[0].Seq)
+	}
+
+	// Stage the changes to the IDs alongside the transaction.
+	staged := map[uint64]T{}
+	lookup := func(id uint64) (T, bool) {
+		if vol, ok := staged[id]; ok {
+			return vol, !vol.IsNil()
+		}
+		vol, ok := r.vols[id]
+		return vol, ok
+	}
+	txn := r.bvh.Begin()
+	seq := r.seq
+	for _, change := range changes {
+		if change.Seq <= seq {
+			continue
+		}
+		seq = change.Seq
+		old, found := lookup(change.ID)
+		switch change.Op {
+		case OpAdd:
+			if found {
+				return fmt.Errorf("%w: id %d", ErrDuplicate, change.ID)
+			}
+			txn.AddLayers(change.Vol, change.Layers)
+			staged[change.ID] = change.Vol
+		case OpRemove, OpUpdate:
+			if !found {
+				return fmt.Errorf("%w: id %d", ErrNotFound, change.ID)
+			}
+			if change.Op == OpRemove {
+				txn.Remove(old)
+				staged[change.ID] = *new(T)
+			} else {
+				txn.Update(old, change.Vol)
+				staged[change.ID] = change.Vol
+			}
+		}
+	}
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+
+	for id, vol := range staged {
+		if vol.IsNil() {
+			delete(r.vols, id)
+		} else {
+			r.vols[id] = vol
+		}
+	}
+	r.seq = seq
+	return nil
+}
+
+// Restore replaces the contents of the replica with an encoded checkpoint from LoggedBVol.Checkpoint. Returns
+// ErrCorrupt if it could not be decoded, in which case the replica is left as it was.
+func (r *Replica[T, E]) Restore(checkpoint []byte) error {
+	if len(checkpoint) == 0 || checkpoint[0] != logVersion {
+		return ErrCorrupt
+	}
+	data := checkpoint[1:]
+	seq, n := binary.Uvarint(data)
+	count, m := binary.Uvarint(data[max(n, 0):])
+	if n <= 0 || m <= 0 {
+		return ErrCorrupt
+	}
+	data = data[n+m:]
+
+	tree := &BVol[T, E]{}
+	if aug := r.bvh.aug(); aug != nil {
+		// Keep the aggregate (such as a Merkle hash) of the replica.
+		tree.setAug(aug.clone())
+		tree.aug().reaggregate(tree)
+	}
+	iter := tree.Iterator()
+	vols := make(map[uint64]T, min(count, uint64(len(data))))
+	for i := uint64(0); i < count; i++ {
+		id, n := binary.Uvarint(data)
+		layers, m := binary.Uvarint(data[max(n, 0):])
+		if n <= 0 || m <= 0 {
+			return ErrCorrupt
+		}
+		vol, size, err := r.codec.ReadVolume(data[n+m:])
+		if err != nil {
+			return err
+		}
+		if err := validVolume(vol); err != nil || !iter.AddLayers(vol, layers) {
+			return ErrCorrupt
+		}
+		vols[id] = vol
+		data = data[n+m+size:]
+	}
+	if len(data) > 0 {
+		return ErrCorrupt
+	}
+	r.bvh, r.vols, r.seq = *tree, vols, seq
+	return nil
+}
+
+// Seq returns the sequence number of the latest change applied.
+func (r *Replica[T, E]) Seq() uint64 {
+	return r.seq
+}
+
+// Volume returns the volume with the ID, or nil if there is none.
+func (r *Replica[T, E]) Volume(id uint64) T {
+	return r.vols[id]
+}
+
+// Iterator for the current state of the replica. It is invalidated by Apply and Restore.
+func (r *Replica[T, E]) Iterator() Reader[T, E] {
+	return r.bvh.Iterator()
+}
+
+// Len returns the number of volumes stored in the replica.
+func (r *Replica[T, E]) Len() int {
+	return r.bvh.Len()
+}
+
+// DecodeChanges decodes the changes from LoggedBVol.Changes, such as for inspecting them. Returns ErrCorrupt if data
+// could not be decoded.
+func DecodeChanges[T math32.VolumeType[E], E math32.Number](codec Codec[T, E], data []byte) ([]Change[T, E], error) {
+	if len(data) == 0 || data[0] != logVersion {
+		return nil, ErrCorrupt
+	}
+	seq, n := binary.Uvarint(data[1:])
+	if n <= 0 {
+		return nil, ErrCorrupt
+	}
+	data = data[1+n:]
+
+	var changes []Change[T, E]
+	for ; len(data) > 0; seq++ {
+		change := Change[T, E]{Seq: seq, Op: Op(data[0])}
+		id, n := binary.Uvarint(data[1:])
+		if n <= 0 || change.Op < OpAdd || change.Op > OpUpdate {
+			return nil, ErrCorrupt
+		}
+		change.ID = id
+		data = data[1+n:]
+		if change.Op == OpAdd {
+			if change.Layers, n = binary.Uvarint(data); n <= 0 {
+				return nil, ErrCorrupt
+			}
+			data = data[n:]
+		}
+		if change.Op != OpRemove {
+			vol, size, err := codec.ReadVolume(data)
+			if err != nil {
+				return nil, err
+			}
+			change.Vol = vol
+			data = data[size:]
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,209 @@
+package collision
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkReplica verifies that the replica has the same volumes, IDs and layers as the server.
+func checkReplica(t *testing.T, server *LoggedBVol[*Orthotope[int32], int32],
+	replica *Replica[*Orthotope[int32], int32]) {
+	t.Helper()
+	if replica.Seq() != server.Seq() || replica.Len() != server.Len() {
+		t.Errorf("Replica has %d volumes at %d, expected %d at %d", replica.Len(), replica.Seq(), server.Len(),
+			server.Seq())
+	}
+	iter := replica.bvh.Iterator()
+	for orth, id := range server.ids {
+		vol := replica.Volume(id)
+		if vol == nil || !vol.Equals(orth) {
+			t.Errorf("Replica has %v for %d, expected %v", vol, id, orth.String())
+		} else if layers := iter.Layers(vol); layers != server.iter.Layers(orth) {
+			t.Errorf("Replica has layers %b for %d, expected %b", layers, id, server.iter.Layers(orth))
+		}
+	}
+	checkBounds(t, &replica.bvh)
+}
+
+// randomChanges adds, removes and moves random volumes of the server n times.
+func randomChanges(server *LoggedBVol[*Orthotope[int32], int32], r *rand.Rand, n int) {
+	orths := make([]*Orthotope[int32], 0, server.Len())
+	for orth := range server.ids {
+		orths = append(orths, orth)
+	}
+	for i := 0; i < n; i++ {
+		switch choice := r.Intn(4); {
+		case choice < 2 || len(orths) == 0:
+			orth := &Orthotope[int32]{
+				Point: Coordinate[int32]{r.Int31n(1000), r.Int31n(1000), r.Int31n(1000)},
+				Delta: Coordinate[int32]{r.Int31n(20), r.Int31n(20), r.Int31n(20)},
+			}
+			server.AddLayers(orth, 1<<r.Intn(4))
+			orths = append(orths, orth)
+		case choice == 2:
+			index := r.Intn(len(orths))
+			server.Remove(orths[index])
+			orths[index] = orths[len(orths)-1]
+			orths = orths[:len(orths)-1]
+		default:
+			server.Update(orths[r.Intn(len(orths))], func(o *Orthotope[int32]) {
+				o.Point[r.Intn(DIMENSIONS)] += r.Int31n(50) - 25
+			})
+		}
+	}
+}
+
+func TestChangeLog(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	server := NewLoggedBVol[*Orthotope[int32], int32](OrthotopeCodec[int32]{}, 0)
+	replica := NewReplica[*Orthotope[int32], int32](OrthotopeCodec[int32]{})
+	for round := 0; round < 20; round++ {
+		randomChanges(server, r, 200)
+		changes, err := server.Changes(replica.Seq())
+		if err != nil {
+			t.Fatalf("Unable to get changes: %v", err)
+		}
+		if err := replica.Apply(changes); err != nil {
+			t.Fatalf("Unable to apply changes: %v", err)
+		}
+		// Applying the same changes again has no effect.
+		if err := replica.Apply(changes); err != nil {
+			t.Errorf("Unable to apply changes twice: %v", err)
+		}
+	}
+	checkReplica(t, server, replica)
+
+	all, _ := server.Changes(0)
+	changes, err := DecodeChanges[*Orthotope[int32], int32](OrthotopeCodec[int32]{}, all)
+	if err != nil || len(changes) != 4000 || changes[0].Seq != 1 || changes[3999].Seq != 4000 {
+		t.Fatalf("Unexpected changes: %d, %v", len(changes), err)
+	}
+	// Adding an orthotope takes an op, ID, layers and six coordinates of at most two bytes each.
+	if size := len(all) / len(changes); size > 12 {
+		t.Errorf("Expected compact changes, got %d bytes per change", size)
+	}
+
+	late := NewReplica[*Orthotope[int32], int32](OrthotopeCodec[int32]{})
+	recent, _ := server.Changes(3000)
+	if err := late.Apply(recent); !errors.Is(err, ErrSequenceGap) {
+		t.Errorf("Expected ErrSequenceGap, got %v", err)
+	}
+	if err := late.Apply(all[:len(all)-1]); !errors.Is(err, ErrCorrupt) || late.Seq() != 0 || late.Len() != 0 {
+		t.Errorf("Expected ErrCorrupt and no changes, got %v", err)
+	}
+	if err := late.Apply(all); err != nil {
+		t.Errorf("Unable to apply every change: %v", err)
+	}
+	checkReplica(t, server, late)
+}
+
+func TestChangeLogEqualBounds(t *testing.T) {
+	a := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{1, 1, 1}}
+	b := &Orthotope[int32]{Point: Coordinate[int32]{2, 2, 2}, Delta: Coordinate[int32]{1, 1, 1}}
+	c := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{3, 3, 3}}
+	server := NewLoggedBVol[*Orthotope[int32], int32](OrthotopeCodec[int32]{}, 0)
+	replica := NewReplica[*Orthotope[int32], int32](OrthotopeCodec[int32]{})
+	catchUp := func() {
+		changes, _ := server.Changes(replica.Seq())
+		if err := replica.Apply(changes); err != nil {
+			t.Fatalf("Unable to apply changes: %v", err)
+		}
+		checkReplica(t, server, replica)
+	}
+
+	// c has the bounds of the root of the server when it is added and removed.
+	for _, orth := range []*Orthotope[int32]{a, b, c} {
+		if err := server.Add(orth); err != nil {
+			t.Errorf("Unable to add %v: %v", orth.String(), err)
+		}
+	}
+	catchUp()
+	if err := server.Remove(c); err != nil || server.Len() != 2 {
+		t.Errorf("Unable to remove %v: %v", c.String(), err)
+	}
+	catchUp()
+
+	// A rejected move restores the volume without recording a change.
+	seq := server.Seq()
+	err := server.Update(b, func(o *Orthotope[int32]) { o.Delta[0] = -1 })
+	if !errors.Is(err, ErrInvalidVolume) || server.Seq() != seq || b.Delta[0] != 1 || !server.iter.Contains(b) {
+		t.Errorf("Expected ErrInvalidVolume and %v to be kept, got %v", b.String(), err)
+	}
+	if err := server.Update(b, func(o *Orthotope[int32]) { o.Point[0] = 5 }); err != nil {
+		t.Errorf("Unable to update %v: %v", b.String(), err)
+	}
+	catchUp()
+}
+
+func TestCheckpoint(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	server := NewLoggedBVol[*Orthotope[int32], int32](OrthotopeCodec[int32]{}, 100)
+	replica := NewReplica[*Orthotope[int32], int32](OrthotopeCodec[int32]{})
+	randomChanges(server, r, 1050)
+	if len(server.offsets) != 150 {
+		t.Errorf("Expected 150 changes after the previous checkpoint, got %d", len(server.offsets))
+	}
+	if _, err := server.Changes(899); !errors.Is(err, ErrLogTruncated) {
+		t.Errorf("Expected ErrLogTruncated, got %v", err)
+	}
+	if _, err := server.Changes(900); err != nil {
+		t.Errorf("Unable to get the changes after the previous checkpoint: %v", err)
+	}
+
+	// A late joiner restores the checkpoint, then applies the changes after it.
+	checkpoint := server.Checkpoint()
+	if err := replica.Restore(checkpoint[:len(checkpoint)-1]); !errors.Is(err, ErrCorrupt) || replica.Len() != 0 {
+		t.Errorf("Expected ErrCorrupt and no changes, got %v", err)
+	}
+	if err := replica.Restore(checkpoint); err != nil || replica.Seq() != 1000 {
+		t.Fatalf("Unable to restore at 1000: %v, %d", err, replica.Seq())
+	}
+	changes, err := server.Changes(replica.Seq())
+	if err != nil {
+		t.Fatalf("Unable to get changes: %v", err)
+	}
+	if err := replica.Apply(changes); err != nil {
+		t.Fatalf("Unable to apply changes: %v", err)
+	}
+	checkReplica(t, server, replica)
+
+	// The replica keeps up across later checkpoints.
+	for round := 0; round < 10; round++ {
+		randomChanges(server, r, 30)
+		changes, err := server.Changes(replica.Seq())
+		if err != nil {
+			t.Fatalf("Unable to get changes at %d: %v", replica.Seq(), err)
+		}
+		if err := replica.Apply(changes); err != nil {
+			t.Fatalf("Unable to apply changes: %v", err)
+		}
+	}
+	checkReplica(t, server, replica)
+}
+
+func TestCodec(t *testing.T) {
+	orth := &Orthotope[float64]{Point: Coordinate[float64]{-1.5, 0.1, 1e300}, Delta: Coordinate[float64]{0, 2, 3}}
+	buf := OrthotopeCodec[float64]{}.AppendVolume([]byte{7}, orth)
+	decoded, n, err := OrthotopeCodec[float64]{}.ReadVolume(buf[1:])
+	if err != nil || n != len(buf)-1 || !decoded.Equals(orth) {
+		t.Errorf("Decoded %v from %d bytes, expected %v: %v", decoded, n, orth.String(), err)
+	}
+
+	sphere := &Sphere[float32]{Center: Coordinate[float32]{1.25, -3, 1e-40}, Radius: 0.3}
+	buf = SphereCodec[float32]{}.AppendVolume(nil, sphere)
+	if len(buf) != 16 {
+		t.Errorf("Expected 16 bytes, got %d", len(buf))
+	}
+	decodedSphere, _, err := SphereCodec[float32]{}.ReadVolume(buf)
+	if err != nil || *decodedSphere != *sphere {
+		t.Errorf("Decoded %v, expected %v: %v", decodedSphere, sphere, err)
+	}
+	for n := range buf {
+		if _, _, err := (SphereCodec[float32]{}).ReadVolume(buf[:n]); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Expected ErrCorrupt for %d bytes, got %v", n, err)
+		}
+	}
+}
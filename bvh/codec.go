@@ -0,0 +1,124 @@
+package collision
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Codec encodes volumes for a change log (see LoggedBVol). OrthotopeCodec and SphereCodec encode the volumes of
+// math32.
+type Codec[T math32.VolumeType[E], E math32.Number] interface {
+	// AppendVolume appends the encoding of vol to buf.
+	AppendVolume(buf []byte, vol T) []byte
+	// ReadVolume decodes a volume from the start of data, and returns the number of bytes read. Returns ErrCorrupt
+	// if data is too short.
+	ReadVolume(data []byte) (T, int, error)
+}
+
+// OrthotopeCodec encodes math32.Orthotope volumes. Integer coordinates are written as varints and floating point
+// coordinates by their bits, so that replicas receive exactly the same bounds.
+type OrthotopeCodec[E math32.Number] struct{}
+
+// AppendVolume appends the point and then the delta of vol.
+func (OrthotopeCodec[E]) AppendVolume(buf []byte, vol *math32.Orthotope[E]) []byte {
+	kind := math32.KindOf[E]()
+	buf = appendCoordinate(buf, kind, vol.Point)
+	return appendCoordinate(buf, kind, vol.Delta)
+}
+
+// ReadVolume decodes an orthotope written by AppendVolume.
+func (OrthotopeCodec[E]) ReadVolume(data []byte) (*math32.Orthotope[E], int, error) {
+	kind := math32.KindOf[E]()
+	vol := &math32.Orthotope[E]{}
+	n := readCoordinate(data, kind, (*math32.Coordinate[E])(&vol.Point))
+	m := readCoordinate(data[max(n, 0):], kind, (*math32.Coordinate[E])(&vol.Delta))
+	if n < 0 || m < 0 {
+		return nil, 0, ErrCorrupt
+	}
+	return vol, n + m, nil
+}
+
+// SphereCodec encodes math32.Sphere volumes like OrthotopeCodec.
+type SphereCodec[E math32.Number] struct{}
+
+// AppendVolume appends the center and then the radius of vol.
+func (SphereCodec[E]) AppendVolume(buf []byte, vol *math32.Sphere[E]) []byte {
+	kind := math32.KindOf[E]()
+	buf = appendCoordinate(buf, kind, vol.Center)
+	return appendNumber(buf, kind, vol.Radius)
+}
+
+// ReadVolume decodes a sphere written by AppendVolume.
+func (SphereCodec[E]) ReadVolume(data []byte) (*math32.Sphere[E], int, error) {
+	kind := math32.KindOf[E]()
+	vol := &math32.Sphere[E]{}
+	n := readCoordinate(data, kind, &vol.Center)
+	if n < 0 {
+		return nil, 0, ErrCorrupt
+	}
+	radius, m := readNumber[E](data[n:], kind)
+	if m <= 0 {
+		return nil, 0, ErrCorrupt
+	}
+	vol.Radius = radius
+	return vol, n + m, nil
+}
+
+// appendCoordinate appends each value of c (see appendNumber).
+func appendCoordinate[E math32.Number](buf []byte, kind math32.Kind, c math32.Coordinate[E]) []byte {
+	for _, value := range c {
+		buf = appendNumber(buf, kind, value)
+	}
+	return buf
+}
+
+// readCoordinate decodes each value of c, and returns the number of bytes read or -1 if data is too short.
+func readCoordinate[E math32.Number](data []byte, kind math32.Kind, c *math32.Coordinate[E]) int {
+	read := 0
+	for d := range c {
+		value, n := readNumber[E](data[read:], kind)
+		if n <= 0 {
+			return -1
+		}
+		c[d] = value
+		read += n
+	}
+	return read
+}
+
+// appendNumber appends floating point values as their little endian bits, and integers as varints. kind is the kind
+// of E, found once per volume rather than for every value.
+func appendNumber[E math32.Number](buf []byte, kind math32.Kind, value E) []byte {
+	switch kind {
+	case math32.KindFloat32:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(value)))
+	case math32.KindFloat64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(float64(value)))
+	default:
+		return binary.AppendVarint(buf, int64(value))
+	}
+}
+
+// readNumber decodes a value written by appendNumber, and returns the number of bytes read or 0 if data is too short.
+func readNumber[E math32.Number](data []byte, kind math32.Kind) (E, int) {
+	switch kind {
+	case math32.KindFloat32:
+		if len(data) < 4 {
+			return 0, 0
+		}
+		return E(math.Float32frombits(binary.LittleEndian.Uint32(data))), 4
+	case math32.KindFloat64:
+		if len(data) < 8 {
+			return 0, 0
+		}
+		return E(math.Float64frombits(binary.LittleEndian.Uint64(data))), 8
+	default:
+		value, n := binary.Varint(data)
+		if n <= 0 {
+			return 0, 0
+		}
+		return E(value), n
+	}
+}
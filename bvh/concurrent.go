@@ -0,0 +1,132 @@
+package collision
+
+import (
+	"sync"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// ConcurrentBVol wraps a BVH such that it may be used from multiple goroutines. Queries take a read lock and use
+// their own pooled iterator, so they never block each other. Additions, removals and updates are serialized.
+type ConcurrentBVol[T math32.VolumeType[E], E math32.Number] struct {
+	lock    sync.RWMutex
+	root    BVol[T, E]
+	writer  *orthStack[T, E]
+	readers sync.Pool
+}
+
+// NewConcurrentBVol creates an empty ConcurrentBVol.
+func NewConcurrentBVol[T math32.VolumeType[E], E math32.Number]() *ConcurrentBVol[T, E] {
+	c := &ConcurrentBVol[T, E]{}
+	c.writer = NewArena[T, E](0).Iterator(&c.root)
+	c.readers.New = func() any {
+		return c.root.Iterator()
+	}
+	return c
+}
+
+// reader takes an iterator from the pool. Return it with c.readers.Put.
+func (c *ConcurrentBVol[T, E]) reader() *orthStack[T, E] {
+	iter := c.readers.Get().(*orthStack[T, E])
+	iter.Reset()
+	return iter
+}
+
+// Add an orth to the BVH. Returns ErrDuplicate if it was already added, or ErrInvalidVolume (see orthStack.Insert).
+func (c *ConcurrentBVol[T, E]) Add(orth T) error {
+	if err := validVolume(orth); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.writer.Insert(orth)
+}
+
+// Remove an orth from the BVH. Returns ErrEmptyTree or ErrNotFound if it was not found (see orthStack.Delete).
+func (c *ConcurrentBVol[T, E]) Remove(orth T) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.writer.Delete(orth)
+}
+
+// Update removes the orth, lets move modify it, then adds it back. Other goroutines never observe the orth while it
+// is removed. Returns ErrNotFound (without calling move) if the orth was not found. If move leaves the orth invalid,
+// ErrInvalidVolume is returned and the orth is restored to its previous bounds.
+func (c *ConcurrentBVol[T, E]) Update(orth T, move func(T)) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.writer.Remove(orth) {
+		return ErrNotFound
+	}
+	saved := cloneVolume(orth)
+	move(orth)
+	if err := c.writer.Insert(orth); err != nil {
+		copyVolume(orth, saved)
+		c.writer.Insert(orth)
+		return err
+	}
+	return nil
+}
+
+// Contains returns true iff the exact orth instance is stored within the BVH.
+func (c *ConcurrentBVol[T, E]) Contains(orth T) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	iter := c.reader()
+	defer c.readers.Put(iter)
+	return iter.Contains(orth)
+}
+
+// Query calls found for each orth in the BVH that overlaps o, until found returns false. found must not modify the
+// ConcurrentBVol.
+func (c *ConcurrentBVol[T, E]) Query(o T, found func(T) bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	iter := c.reader()
+	defer c.readers.Put(iter)
+	for r := iter.Query(o); !r.IsNil(); r = iter.Query(o) {
+		if !found(r) {
+			return
+		}
+	}
+}
+
+// Intersects calls found for each orth (and distance) hit when moving orth along delta, until found returns false. It
+// does not guarantee order. found must not modify the ConcurrentBVol.
+func (c *ConcurrentBVol[T, E]) Intersects(orth T, delta *math32.Coordinate[E], found func(T, E) bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	iter := c.reader()
+	defer c.readers.Put(iter)
+	for r, d := iter.Intersects(orth, delta); !r.IsNil(); r, d = iter.Intersects(orth, delta) {
+		if !found(r, d) {
+			return
+		}
+	}
+}
+
+// Nearest returns the orth closest to point and the squared distance to its bounds, or -1 when the BVH is empty.
+func (c *ConcurrentBVol[T, E]) Nearest(point math32.Coordinate[E]) (T, E) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	iter := c.reader()
+	defer c.readers.Put(iter)
+	return iter.Nearest(point)
+}
+
+// Walk visits the volumes of the BVH, skipping the descendants of those whose bounds fail nodeTest. The
+// functions must not modify the ConcurrentBVol.
+func (c *ConcurrentBVol[T, E]) Walk(nodeTest func(bounds T, depth int32) bool, leafFn func(T) bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	iter := c.reader()
+	defer c.readers.Put(iter)
+	iter.Walk(nodeTest, leafFn)
+}
+
+// GetDepth returns the height of the tree.
+func (c *ConcurrentBVol[T, E]) GetDepth() int32 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.root.GetDepth()
+}
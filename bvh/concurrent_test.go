@@ -0,0 +1,179 @@
+package collision
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestConcurrentBVol(t *testing.T) {
+	tree := NewConcurrentBVol[*Orthotope[int32], int32]()
+	for _, orth := range leaf {
+		o := &Orthotope[int32]{}
+		for d := range o.Point {
+			o.Point[d], o.Delta[d] = int32(orth.Point[d]), int32(orth.Delta[d])
+		}
+		if err := tree.Add(o); err != nil {
+			t.Errorf("Unable to add %v: %v\n", o.String(), err)
+		}
+	}
+	if tree.GetDepth() != 4 {
+		t.Errorf("Unexpected depth: %d\nExpected: 4\n", tree.GetDepth())
+	}
+
+	q := &Orthotope[int32]{Point: Coordinate[int32]{17, 9}, Delta: Coordinate[int32]{5, 5}}
+	var found []*Orthotope[int32]
+	tree.Query(q, func(r *Orthotope[int32]) bool {
+		found = append(found, r)
+		return true
+	})
+	if len(found) != 3 {
+		t.Errorf("Expected 3 volumes, found %v", found)
+	}
+
+	moved := found[0]
+	if err := tree.Update(moved, func(o *Orthotope[int32]) { o.Point[0] += 100 }); err != nil {
+		t.Errorf("Unable to update %v: %v\n", moved.String(), err)
+	}
+	count := 0
+	tree.Query(q, func(r *Orthotope[int32]) bool {
+		count++
+		return true
+	})
+	if count != 2 || !tree.Contains(moved) {
+		t.Errorf("Expected 2 volumes after moving %v, found %d", moved.String(), count)
+	}
+
+	if nearest, _ := tree.Nearest(Coordinate[int32]{moved.Point[0], moved.Point[1]}); nearest != moved {
+		t.Errorf("Expected %v to be nearest, got %v", moved.String(), nearest.String())
+	}
+	leaves := 0
+	tree.Walk(func(*Orthotope[int32], int32) bool { return true }, func(*Orthotope[int32]) bool {
+		leaves++
+		return true
+	})
+	if leaves != len(leaf) {
+		t.Errorf("Walk visited %d leaves, expected %d", leaves, len(leaf))
+	}
+
+	removed := tree.Remove(moved) == nil && errors.Is(tree.Remove(moved), ErrNotFound)
+	if err := tree.Update(moved, func(*Orthotope[int32]) {}); !removed || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unexpected result removing %v twice", moved.String())
+	}
+	if err := tree.Add(found[1]); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+
+	// Invalid volumes are rejected, including those made invalid by an update.
+	huge := &Orthotope[int32]{Point: Coordinate[int32]{math.MaxInt32 - 1}, Delta: Coordinate[int32]{2}}
+	if err := tree.Add(huge); !errors.Is(err, ErrOverflow) || tree.Contains(huge) {
+		t.Errorf("Expected ErrOverflow adding %v, got %v", huge.String(), err)
+	}
+	before := *found[1]
+	err := tree.Update(found[1], func(o *Orthotope[int32]) { o.Delta[2] = -1 })
+	if !errors.Is(err, ErrNegativeDelta) || !tree.Contains(found[1]) || *found[1] != before {
+		t.Errorf("Expected ErrNegativeDelta and %v to be restored, got %v and %v", before.String(), err,
+			found[1].String())
+	}
+	if bounds := tree.root.vol; bounds.Validate() != nil || bounds.Delta[2] < 0 {
+		t.Errorf("Unexpected bounds after rejecting volumes: %v", bounds.String())
+	}
+}
+
+// handle is a volume that is not a pointer, which Update must still be able to restore.
+type handle struct {
+	*Orthotope[int32]
+}
+
+func (h handle) New() VolumeType[int32] {
+	return handle{&Orthotope[int32]{}}
+}
+
+func (h handle) IsNil() bool {
+	return h.Orthotope == nil
+}
+
+func (h handle) IsSame(other VolumeType[int32]) bool {
+	o, ok := other.(handle)
+	return ok && o.Orthotope == h.Orthotope
+}
+
+func (h handle) Equals(other VolumeType[int32]) bool {
+	o, ok := other.(handle)
+	return ok && h.Orthotope.Equals(o.Orthotope)
+}
+
+func TestConcurrentBVolUpdateHandle(t *testing.T) {
+	tree := NewConcurrentBVol[handle, int32]()
+	handles := make([]handle, 0, 100)
+	for _, orth := range randomOrths(100) {
+		handles = append(handles, handle{orth})
+		if err := tree.Add(handles[len(handles)-1]); err != nil {
+			t.Fatalf("Unable to add %v: %v", orth.String(), err)
+		}
+	}
+	before := *handles[7].Orthotope
+	err := tree.Update(handles[7], func(h handle) { h.Delta[0] = -1 })
+	if !errors.Is(err, ErrNegativeDelta) || !tree.Contains(handles[7]) || *handles[7].Orthotope != before {
+		t.Errorf("Expected ErrNegativeDelta and %v to be restored, got %v and %v", before.String(), err,
+			handles[7].String())
+	}
+	if err := tree.Update(handles[8], func(h handle) { h.Point[0] += 5 }); err != nil || !tree.Contains(handles[8]) {
+		t.Errorf("Unable to update %v: %v", handles[8].String(), err)
+	}
+}
+
+// TestConcurrentBVolRace mixes queries and updates across goroutines. Run with -race.
+func TestConcurrentBVolRace(t *testing.T) {
+	orths := randomOrths(400)
+	tree := NewConcurrentBVol[*Orthotope[int32], int32]()
+	for _, orth := range orths[:200] {
+		tree.Add(orth)
+	}
+
+	var wg sync.WaitGroup
+	// Writers own disjoint volumes, so each can check its own results.
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(owned []*Orthotope[int32]) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(len(owned))))
+			for i := 0; i < 200; i++ {
+				orth := owned[r.Intn(len(owned))]
+				switch r.Intn(3) {
+				case 0:
+					if tree.Contains(orth) != errors.Is(tree.Add(orth), ErrDuplicate) {
+						t.Errorf("Add returned an unexpected result for %v", orth.String())
+					}
+				case 1:
+					if tree.Contains(orth) != (tree.Remove(orth) == nil) {
+						t.Errorf("Remove returned an unexpected result for %v", orth.String())
+					}
+				default:
+					tree.Update(orth, func(o *Orthotope[int32]) { o.Point[1] = r.Int31n(1000) })
+				}
+			}
+		}(orths[w*100 : (w+1)*100])
+	}
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queries := randomOrths(50)
+			for _, q := range queries {
+				q.Delta = Coordinate[int32](q.Delta).Scale(10)
+				tree.Query(q, func(r *Orthotope[int32]) bool {
+					return r.Overlaps(q)
+				})
+				tree.Nearest(Coordinate[int32](q.Point))
+				delta := &Coordinate[int32]{100, 0, 0}
+				tree.Intersects(q, delta, func(*Orthotope[int32], int32) bool { return true })
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,143 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// cowState tracks which nodes belong to the current generation of a PersistentBVol. Nodes from older generations
+// may be shared with snapshots and are copied before they are modified.
+type cowState struct {
+	gen    uint32
+	copies int
+}
+
+// copy returns bvol if it belongs to the current generation, or else a copy of it that does.
+func copyNode[T math32.VolumeType[E], E math32.Number](c *cowState, bvol *BVol[T, E]) *BVol[T, E] {
+	if bvol.gen == c.gen {
+		return bvol
+	}
+	node := *bvol
+	node.gen = c.gen
+	if node.depth > 0 {
+		// Internal volumes are modified in place by rebalancing, leaves are not.
+		node.vol = bvol.vol.New().(T)
+		node.vol.MinBounds(bvol.vol)
+	}
+	if node.opt != nil {
+		opt := *node.opt
+		if opt.aug != nil {
+			opt.aug = opt.aug.clone()
+		}
+		node.opt = &opt
+	}
+	c.copies++
+	return &node
+}
+
+// own copies the nodes on the stack, as well as their children and grandchildren, that are shared with a snapshot.
+// Rebalancing after an addition or removal only modifies volumes within two levels of the stack.
+func (s *orthStack[T, E]) own() {
+	if s.cow == nil {
+		return
+	}
+	s.bvh = copyNode(s.cow, s.bvh)
+	s.replace(s.bvStack[0], s.bvh)
+
+	for _, bvol := range s.bvStack {
+		if bvol.depth == 0 {
+			continue
+		}
+		for index, child := range bvol.desc {
+			owned := copyNode(s.cow, child)
+			bvol.desc[index] = owned
+			s.replace(child, owned)
+			if owned.depth > 0 {
+				for gIndex, gChild := range owned.desc {
+					owned.desc[gIndex] = copyNode(s.cow, gChild)
+					s.replace(gChild, owned.desc[gIndex])
+				}
+			}
+		}
+	}
+}
+
+// replace every reference to a node on the stack. Add places the root on the stack twice.
+func (s *orthStack[T, E]) replace(old, owned *BVol[T, E]) {
+	if old == owned {
+		return
+	}
+	for i, bvol := range s.bvStack {
+		if bvol == old {
+			s.bvStack[i] = owned
+		}
+	}
+}
+
+// PersistentBVol is a BVH with O(1) snapshots. After a Snapshot, additions and removals copy the nodes they modify
+// rather than changing them, so every snapshot remains valid and may be queried from other goroutines without locks.
+// A PersistentBVol itself is not thread-safe.
+type PersistentBVol[T math32.VolumeType[E], E math32.Number] struct {
+	cow  cowState
+	iter *orthStack[T, E]
+}
+
+// NewPersistentBVol creates an empty PersistentBVol.
+func NewPersistentBVol[T math32.VolumeType[E], E math32.Number]() *PersistentBVol[T, E] {
+	p := &PersistentBVol[T, E]{}
+	p.iter = (&BVol[T, E]{}).Iterator()
+	p.iter.cow = &p.cow
+	return p
+}
+
+// Add an orth to the BVH. Returns false if it was already added.
+func (p *PersistentBVol[T, E]) Add(orth T) bool {
+	return p.iter.Add(orth)
+}
+
+// Remove an orth from the BVH. Returns false if it was not found.
+func (p *PersistentBVol[T, E]) Remove(orth T) bool {
+	return p.iter.Remove(orth)
+}
+
+// Iterator for the current state of the BVH. It is invalidated by Add and Remove; use a Snapshot instead for reading
+// while the BVH changes.
+func (p *PersistentBVol[T, E]) Iterator() Reader[T, E] {
+	return p.iter.bvh.Iterator()
+}
+
+// GetDepth returns the height of the tree.
+func (p *PersistentBVol[T, E]) GetDepth() int32 {
+	return p.iter.bvh.depth
+}
+
+// Snapshot returns an immutable view of the current state of the BVH.
+func (p *PersistentBVol[T, E]) Snapshot() *Snapshot[T, E] {
+	p.cow.gen++
+	return &Snapshot[T, E]{root: p.iter.bvh}
+}
+
+// Snapshot is an immutable view of a PersistentBVol.
+type Snapshot[T math32.VolumeType[E], E math32.Number] struct {
+	root *BVol[T, E]
+}
+
+// Iterator for querying the snapshot. Iterators are not thread-safe; create one per goroutine.
+func (s *Snapshot[T, E]) Iterator() Reader[T, E] {
+	return s.root.Iterator()
+}
+
+// GetDepth returns the height of the tree when the snapshot was taken.
+func (s *Snapshot[T, E]) GetDepth() int32 {
+	return s.root.depth
+}
+
+// Reader gives the methods of an iterator that do not modify the BVH.
+type Reader[T math32.VolumeType[E], E math32.Number] interface {
+	Reset()
+	HasNext() bool
+	Query(o T) T
+	Intersects(orth T, delta *math32.Coordinate[E]) (T, E)
+	Nearest(point math32.Coordinate[E]) (T, E)
+	Contains(o T) bool
+	Walk(nodeTest func(bounds T, depth int32) bool, leafFn func(T) bool)
+}
@@ -0,0 +1,117 @@
+package collision
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestSnapshot(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := NewPersistentBVol[*Orthotope[int32], int32]()
+	empty := tree.Snapshot()
+	for _, orth := range orths[:500] {
+		tree.Add(orth)
+	}
+
+	snap := tree.Snapshot()
+	expected := snap.root.String()
+	depth := snap.GetDepth()
+
+	for _, orth := range orths[500:] {
+		if !tree.Add(orth) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths[:400] {
+		if !tree.Remove(orth) {
+			t.Errorf("Unable to remove: %v\n", orth.String())
+		}
+	}
+
+	if snap.root.String() != expected || snap.GetDepth() != depth {
+		t.Errorf("Snapshot was modified by later additions and removals.")
+	}
+	checkBounds(t, snap.root)
+	checkBounds(t, tree.iter.bvh)
+
+	snapIter := snap.Iterator()
+	iter := tree.Iterator()
+	for i, orth := range orths {
+		if snapIter.Contains(orth) != (i < 500) {
+			t.Errorf("Unexpected snapshot membership for %v\n", orth.String())
+		}
+		if iter.Contains(orth) != (i >= 400) {
+			t.Errorf("Unexpected membership for %v\n", orth.String())
+		}
+	}
+	if r := empty.Iterator().Query(orths[0]); r != nil {
+		t.Errorf("Querying an empty snapshot returned %v", r.String())
+	}
+}
+
+func TestSnapshotCopies(t *testing.T) {
+	orths := randomOrths(4000)
+	tree := NewPersistentBVol[*Orthotope[int32], int32]()
+	for _, orth := range orths[:3000] {
+		tree.Add(orth)
+	}
+
+	// Only the nodes near the path should be copied.
+	limit := int(8 * tree.GetDepth())
+	for _, orth := range orths[3000:] {
+		tree.Snapshot()
+		tree.cow.copies = 0
+		tree.Add(orth)
+		if tree.cow.copies > limit {
+			t.Errorf("Adding copied %d nodes; expected at most %d", tree.cow.copies, limit)
+		}
+	}
+	tree.Snapshot()
+	tree.cow.copies = 0
+	for _, orth := range orths[3000:] {
+		tree.Remove(orth)
+	}
+	if tree.cow.copies > limit*1000 {
+		t.Errorf("Removing copied %d nodes", tree.cow.copies)
+	}
+}
+
+// TestSnapshotReaders queries snapshots from other goroutines while the tree changes. Run with -race.
+func TestSnapshotReaders(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := NewPersistentBVol[*Orthotope[int32], int32]()
+	for _, orth := range orths[:500] {
+		tree.Add(orth)
+	}
+
+	var wg sync.WaitGroup
+	q := &Orthotope[int32]{Point: Coordinate[int32]{100, 100, 100}, Delta: Coordinate[int32]{500, 500, 500}}
+	r := rand.New(rand.NewSource(1))
+	for frame := 0; frame < 20; frame++ {
+		snap := tree.Snapshot()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first, second := 0, 0
+			iter := snap.Iterator()
+			for r := iter.Query(q); r != nil; r = iter.Query(q) {
+				first++
+			}
+			iter.Reset()
+			for r := iter.Query(q); r != nil; r = iter.Query(q) {
+				second++
+			}
+			if first != second {
+				t.Errorf("Snapshot changed between queries: %d, %d", first, second)
+			}
+		}()
+		for i := 0; i < 25; i++ {
+			tree.Remove(orths[r.Intn(500)])
+			tree.Add(orths[500+r.Intn(500)])
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,79 @@
+package collision
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+var (
+	// ErrDuplicate is returned when adding a volume that is already in the BVH.
+	ErrDuplicate = errors.New("collision: volume already added")
+	// ErrNotFound is returned when a volume is not in the BVH.
+	ErrNotFound = errors.New("collision: volume not found")
+	// ErrInvalidVolume is returned for nil volumes, and those rejected by math32.Validate. The error from Validate is
+	// wrapped as well, so errors.Is also matches math32.ErrNotFinite, ErrNegativeDelta and ErrOverflow.
+	ErrInvalidVolume = errors.New("collision: invalid volume")
+	// ErrEmptyTree is returned when removing from a BVH without volumes.
+	ErrEmptyTree = errors.New("collision: empty tree")
+	// ErrTxnDone is returned when committing a transaction that was already committed or rolled back.
+	ErrTxnDone = errors.New("collision: transaction already done")
+	// ErrCorrupt is returned when encoded changes, checkpoints or volumes could not be decoded.
+	ErrCorrupt = errors.New("collision: corrupt encoding")
+	// ErrLogTruncated is returned when the changes requested from a LoggedBVol were dropped by a checkpoint.
+	ErrLogTruncated = errors.New("collision: change log truncated")
+	// ErrSequenceGap is returned when a Replica is given changes that do not follow the last one it applied.
+	ErrSequenceGap = errors.New("collision: changes missing")
+	// ErrFormat is returned when opening a file that is not a MappedBVH with the expected dimensions and numeric type.
+	ErrFormat = errors.New("collision: invalid file format")
+	// ErrChecksum is returned when the checksum of a MappedBVH does not match its contents.
+	ErrChecksum = errors.New("collision: checksum mismatch")
+	// ErrNoPage is returned by MemoryStorage for pages that are not allocated.
+	ErrNoPage = errors.New("collision: page not allocated")
+)
+
+// validVolume returns ErrInvalidVolume unless orth may be added to a BVH.
+func validVolume[T math32.VolumeType[E], E math32.Number](orth T) error {
+	if orth.IsNil() {
+		return fmt.Errorf("%w: nil", ErrInvalidVolume)
+	}
+	if err := math32.Validate[E](orth); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidVolume, err)
+	}
+	return nil
+}
+
+// Insert adds an orth to a Bounding Volume Hierarchy like Add, but returns ErrInvalidVolume or ErrDuplicate rather
+// than false.
+func (s *orthStack[T, E]) Insert(orth T) error {
+	if err := validVolume(orth); err != nil {
+		return err
+	}
+	if !s.Add(orth) {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Delete removes an orth from a Bounding Volume Hierarchy like Remove, but returns ErrEmptyTree or ErrNotFound rather
+// than false.
+func (s *orthStack[T, E]) Delete(o T) error {
+	if s.bvh.vol.IsNil() {
+		return ErrEmptyTree
+	}
+	if o.IsNil() || !s.Remove(o) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Insert an orth to a Bounding Volume Hierarchy (see orthStack.Insert). Only insert into the root volume.
+func (b *BVol[T, E]) Insert(orth T) error {
+	return b.Iterator().Insert(orth)
+}
+
+// Delete an orth from a Bounding Volume Hierarchy (see orthStack.Delete). Only delete from the root volume.
+func (b *BVol[T, E]) Delete(o T) error {
+	return b.Iterator().Delete(o)
+}
@@ -0,0 +1,100 @@
+package collision
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestInsertDelete(t *testing.T) {
+	tree := &BVol[*Orthotope[float32], float32]{}
+	iter := tree.Iterator()
+	orth := &Orthotope[float32]{Point: Coordinate[float32]{1, 2, 3}, Delta: Coordinate[float32]{4, 5, 6}}
+
+	if err := tree.Delete(orth); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Expected ErrEmptyTree, got %v", err)
+	}
+	if err := tree.Insert(orth); err != nil {
+		t.Errorf("Unable to insert %v: %v", orth.String(), err)
+	}
+	if err := iter.Insert(orth); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Expected ErrDuplicate, got %v", err)
+	}
+	for _, o := range leaf {
+		if err := iter.Insert(o); err != nil {
+			t.Errorf("Unable to insert %v: %v", o.String(), err)
+		}
+	}
+
+	invalid := []struct {
+		orth *Orthotope[float32]
+		err  error
+	}{
+		{nil, ErrInvalidVolume},
+		{&Orthotope[float32]{Point: Coordinate[float32]{float32(math.NaN())}}, ErrNotFinite},
+		{&Orthotope[float32]{Delta: Coordinate[float32]{1, -1, 1}}, ErrNegativeDelta},
+		{&Orthotope[float32]{Point: Coordinate[float32]{math.MaxFloat32}}, ErrOverflow},
+	}
+	for _, c := range invalid {
+		err := iter.Insert(c.orth)
+		if !errors.Is(err, ErrInvalidVolume) || !errors.Is(err, c.err) {
+			t.Errorf("Expected %v inserting %v, got %v", c.err, c.orth, err)
+		}
+	}
+	if tree.Len() != len(leaf)+1 {
+		t.Errorf("Expected %d volumes, got %d", len(leaf)+1, tree.Len())
+	}
+
+	missing := &Orthotope[float32]{Point: Coordinate[float32]{100, 100}, Delta: Coordinate[float32]{1, 1}}
+	for _, o := range []*Orthotope[float32]{missing, nil} {
+		if err := iter.Delete(o); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound deleting %v, got %v", o, err)
+		}
+	}
+	if err := tree.Delete(orth); err != nil {
+		t.Errorf("Unable to delete %v: %v", orth.String(), err)
+	}
+	if err := tree.Delete(orth); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+	for _, o := range leaf {
+		if err := iter.Delete(o); err != nil {
+			t.Errorf("Unable to delete %v: %v", o.String(), err)
+		}
+	}
+	if err := iter.Delete(leaf[0]); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Expected ErrEmptyTree after deleting every volume, got %v", err)
+	}
+}
+
+func TestInsertEqualBounds(t *testing.T) {
+	a := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{1, 1, 1}}
+	b := &Orthotope[int32]{Point: Coordinate[int32]{2, 2, 2}, Delta: Coordinate[int32]{1, 1, 1}}
+	c := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{3, 3, 3}}
+	tree := &BVol[*Orthotope[int32], int32]{}
+	tree.Insert(a)
+	tree.Insert(b)
+
+	// c has the bounds of the root, which must not be mistaken for a leaf.
+	if err := tree.Delete(c); !errors.Is(err, ErrNotFound) || tree.Len() != 2 {
+		t.Errorf("Expected ErrNotFound and 2 volumes, got %v and %d", err, tree.Len())
+	}
+	if err := tree.Insert(c); err != nil || tree.Len() != 3 || !tree.Iterator().Contains(c) {
+		t.Errorf("Unable to insert %v: %v", c.String(), err)
+	}
+	copied := &Orthotope[int32]{Point: a.Point, Delta: a.Delta}
+	if err := tree.Insert(copied); err != nil || tree.Len() != 4 || !tree.Iterator().Contains(copied) {
+		t.Errorf("Unable to insert a copy of %v: %v", a.String(), err)
+	}
+	checkBounds(t, tree)
+	for _, orth := range []*Orthotope[int32]{c, a, b, copied} {
+		if err := tree.Delete(orth); err != nil {
+			t.Errorf("Unable to delete %v: %v", orth.String(), err)
+		}
+	}
+	if tree.Len() != 0 {
+		t.Errorf("Expected an empty tree, got %d volumes", tree.Len())
+	}
+}
@@ -0,0 +1,246 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// flatNode stores the bounds of a volume inline. Nodes are stored depth first, so the first child of an internal node
+// directly follows it.
+type flatNode[E math32.Number] struct {
+	min    math32.Coordinate[E]
+	max    math32.Coordinate[E]
+	second int32 // Index of the second child. Unused for leaves.
+	skip   int32 // Index of the first node after this subtree.
+	leaf   int32 // Index into FlatBVH.vols, or -1 for internal nodes.
+	depth  int32
+}
+
+// FlatBVH is an immutable, array based copy of a BVol (see BVol.Freeze). It avoids the pointer chasing of BVol
+// when querying and may be shared across goroutines without locks, as long as each goroutine uses its own iterator.
+type FlatBVH[T math32.VolumeType[E], E math32.Number] struct {
+	nodes []flatNode[E]
+	vols  []T
+}
+
+// Freeze creates a FlatBVH with the same hierarchy as the BVH. Later changes to the BVH do not affect it.
+func (b *BVol[T, E]) Freeze() *FlatBVH[T, E] {
+	flat := &FlatBVH[T, E]{}
+	if !b.vol.IsNil() {
+		flat.freeze(b)
+	}
+	return flat
+}
+
+// freeze appends the volume and its descendants in depth first order.
+func (f *FlatBVH[T, E]) freeze(b *BVol[T, E]) {
+	index := len(f.nodes)
+	point, delta := b.vol.GetPoint(), b.vol.GetDelta()
+	f.nodes = append(f.nodes, flatNode[E]{min: point, max: point.Add(delta), leaf: -1, depth: b.depth})
+
+	if b.depth == 0 {
+		f.nodes[index].leaf = int32(len(f.vols))
+		f.vols = append(f.vols, b.vol)
+	} else {
+		f.freeze(b.desc[0])
+		f.nodes[index].second = int32(len(f.nodes))
+		f.freeze(b.desc[1])
+	}
+	f.nodes[index].skip = int32(len(f.nodes))
+}
+
+// GetDepth of the root volume, ie. the height of the tree.
+func (f *FlatBVH[T, E]) GetDepth() int32 {
+	if len(f.nodes) == 0 {
+		return 0
+	}
+	return f.nodes[0].depth
+}
+
+// Len returns the number of volumes stored.
+func (f *FlatBVH[T, E]) Len() int {
+	return len(f.vols)
+}
+
+// Iterator for querying the FlatBVH. Iterators are not thread-safe; create one per goroutine.
+func (f *FlatBVH[T, E]) Iterator() *flatStack[T, E] {
+	return &flatStack[T, E]{flat: f}
+}
+
+// flatStack provides the query methods of orthStack for a FlatBVH.
+type flatStack[T math32.VolumeType[E], E math32.Number] struct {
+	flat  *FlatBVH[T, E]
+	next  int32
+	stack []int32
+}
+
+// Reset the iterator to the root of the FlatBVH.
+func (s *flatStack[T, E]) Reset() {
+	s.next = 0
+	s.stack = s.stack[:0]
+}
+
+// HasNext return true iff the tree has unvisited nodes.
+func (s *flatStack[T, E]) HasNext() bool {
+	return int(s.next) < len(s.flat.nodes)
+}
+
+// Query looks for intersections between the orth, o, and the FlatBVH returning one intersection at a time.
+func (s *flatStack[T, E]) Query(o T) T {
+	nodes := s.flat.nodes
+	point, delta := o.GetPoint(), o.GetDelta()
+	max := point.Add(delta)
+
+	for int(s.next) < len(nodes) {
+		node := &nodes[s.next]
+		if !overlapsBounds(node.min, node.max, point, max) {
+			s.next = node.skip
+		} else if node.leaf >= 0 {
+			s.next = node.skip
+			if vol := s.flat.vols[node.leaf]; vol.Overlaps(o) {
+				return vol
+			}
+		} else {
+			s.next++
+		}
+	}
+	var zero T
+	return zero
+}
+
+// Intersects traces the path of a moving orth through the FlatBVH returning an orth and the distance from the source
+// orth's origin along it's delta. It does not guarantee order.
+func (s *flatStack[T, E]) Intersects(orth T, delta *math32.Coordinate[E]) (T, E) {
+	nodes := s.flat.nodes
+	point, size := orth.GetPoint(), orth.GetDelta()
+	one, div := math32.Unit[E](), math32.DivFunc[E]()
+
+	for int(s.next) < len(nodes) {
+		node := &nodes[s.next]
+		if t := intersectsBounds(node.min, node.max, point, size, delta, div); t < 0 || t > one {
+			s.next = node.skip
+		} else if node.leaf >= 0 {
+			s.next = node.skip
+			vol := s.flat.vols[node.leaf]
+			if t = vol.Intersects(orth, delta); t >= 0 && t <= one {
+				return vol, t
+			}
+		} else {
+			s.next++
+		}
+	}
+	var zero T
+	return zero, -1
+}
+
+// Nearest returns the volume closest to point and the squared distance to its bounds, or -1 when the FlatBVH is
+// empty. It does not change the position of the iterator for Query or Intersects.
+func (s *flatStack[T, E]) Nearest(point math32.Coordinate[E]) (T, E) {
+	var best T
+	var bestDist E = -1
+	nodes := s.flat.nodes
+	if len(nodes) == 0 {
+		return best, bestDist
+	}
+
+	mul := math32.MulFunc[E]()
+	s.stack = append(s.stack[:0], 0)
+	for len(s.stack) > 0 {
+		index := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		node := &nodes[index]
+		dist := distanceSq(point, node.min, node.max, mul)
+		if bestDist >= 0 && dist >= bestDist {
+			continue
+		}
+		if node.leaf >= 0 {
+			best, bestDist = s.flat.vols[node.leaf], dist
+			continue
+		}
+
+		// Visit the closer child first by pushing it last.
+		first, second := index+1, node.second
+		if distanceSq(point, nodes[second].min, nodes[second].max, mul) >
+			distanceSq(point, nodes[first].min, nodes[first].max, mul) {
+			first, second = second, first
+		}
+		s.stack = append(s.stack, first, second)
+	}
+	return best, bestDist
+}
+
+// overlapsBounds mirrors Orthotope.Overlaps for bounds given as minimum and maximum corners.
+func overlapsBounds[E math32.Number](min, max, otherMin, otherMax math32.Coordinate[E]) bool {
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		if min[d] > otherMax[d] || otherMin[d] > max[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// intersectsBounds mirrors Orthotope.Intersects for bounds given as minimum and maximum corners, returning 2 *
+// math32.Unit for misses. div is from math32.DivFunc.
+func intersectsBounds[E math32.Number](min, max, point, size math32.Coordinate[E], delta *math32.Coordinate[E],
+	div func(a, b E) E) E {
+	var inT E = 0
+	outT := math32.Unit[E]()
+	miss := 2 * outT
+
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		p0 := point[d]
+		p1 := size[d] + p0
+
+		if !math32.Finite(delta[d]) {
+			return miss
+		} else if math32.Abs(delta[d]) < math32.SmallestNormal[E]() {
+			// Zero and subnormal directions are parallel to the slab, as in Orthotope.Intersects.
+			if min[d] > p1 || p0 > max[d] {
+				return miss
+			}
+		} else {
+			var p0T, p1T E
+			if div != nil {
+				p0T, p1T = div(min[d]-p1, delta[d]), div(max[d]-p0, delta[d])
+			} else {
+				p0T, p1T = (min[d]-p1)/delta[d], (max[d]-p0)/delta[d]
+			}
+
+			if delta[d] < 0 {
+				// Swap p0 and p1 for negative directions.
+				p0T, p1T = p1T, p0T
+			}
+			inT = math32.Max(inT, p0T)
+			outT = math32.Min(outT, p1T)
+
+			// Written such that NaN does not intersect.
+			if !(inT <= outT) {
+				return miss
+			}
+		}
+	}
+
+	if !(inT >= 0) {
+		return miss
+	}
+	return inT
+}
+
+// distanceSq returns the squared distance from point to the bounds given as minimum and maximum corners, saturating
+// at math32.MaxValue rather than overflowing. mul is from math32.MulFunc.
+func distanceSq[E math32.Number](point, min, max math32.Coordinate[E], mul func(a, b E) E) E {
+	var dist E
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		var diff E
+		if point[d] < min[d] {
+			diff = min[d] - point[d]
+		} else if point[d] > max[d] {
+			diff = point[d] - max[d]
+		}
+		if mul != nil {
+			dist = math32.AddSat(dist, mul(diff, diff))
+		} else {
+			dist = math32.AddSat(dist, math32.SquareSat(diff))
+		}
+	}
+	return dist
+}
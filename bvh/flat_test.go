@@ -0,0 +1,220 @@
+package collision
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/briannoyama/bvh/math32"
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestFreeze(t *testing.T) {
+	tree := getIdealTree()
+	flat := tree.Freeze()
+	if flat.Len() != len(leaf) {
+		t.Errorf("Expected %d volumes, got %d", len(leaf), flat.Len())
+	}
+	if flat.GetDepth() != tree.GetDepth() {
+		t.Errorf("Unexpected depth: %d\nExpected: %d\n", flat.GetDepth(), tree.GetDepth())
+	}
+
+	// Nodes are stored in the same order as the iterator visits them.
+	iter := tree.Iterator()
+	for index := 0; iter.HasNext(); index++ {
+		next := iter.Next()
+		node := flat.nodes[index]
+		if node.min != next.vol.GetPoint() || node.depth != next.depth {
+			t.Errorf("Node %d does not match %v", index, next.vol.String())
+		}
+		if (node.leaf >= 0) != (next.depth == 0) || (node.leaf >= 0 && flat.vols[node.leaf] != next.vol) {
+			t.Errorf("Leaf %d does not match %v", index, next.vol.String())
+		}
+	}
+
+	empty := (&BVol[*math32.Orthotope[float32], float32]{}).Freeze()
+	if r := empty.Iterator().Query(leaf[0]); r != nil {
+		t.Errorf("Querying an empty hierarchy returned non nil value!\n")
+	}
+	if _, d := empty.Iterator().Nearest(Coordinate[float32]{}); d != -1 {
+		t.Errorf("Nearest for an empty hierarchy returned distance %v\n", d)
+	}
+}
+
+func TestFlatQuery(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := TopDownBVH[*Orthotope[int32], int32](orths)
+	flat := tree.Freeze()
+
+	iter := tree.Iterator()
+	flatIter := flat.Iterator()
+	queries := randomOrths(100)
+	for _, q := range queries {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+		expected := map[*Orthotope[int32]]bool{}
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+			expected[r] = true
+		}
+
+		flatIter.Reset()
+		for r := flatIter.Query(q); r != nil; r = flatIter.Query(q) {
+			if !expected[r] {
+				t.Errorf("Querying %v returned unexpected value: %v\n", q.String(), r.String())
+			}
+			delete(expected, r)
+		}
+		for r := range expected {
+			t.Errorf("Querying %v did not return %v\n", q.String(), r.String())
+		}
+	}
+}
+
+// sweepCases returns moving orths and their deltas for comparing sweeps with those of getIdealTree. Non-finite
+// directions do not intersect, and subnormal directions are parallel. See Orthotope.Intersects.
+func sweepCases() ([6]*Orthotope[float32], [6]*Coordinate[float32]) {
+	nan, inf, subnormal := float32(math.NaN()), float32(math.Inf(1)), math.Float32frombits(1)
+	query := [6]*Orthotope[float32]{
+		{Point: Coordinate[float32]{-2, 0}, Delta: Coordinate[float32]{4, 2}},
+		{Point: Coordinate[float32]{7, 20}, Delta: Coordinate[float32]{2, 2}},
+		{Point: Coordinate[float32]{30, 30}, Delta: Coordinate[float32]{1, 1}},
+		{Point: Coordinate[float32]{-2, 0}, Delta: Coordinate[float32]{4, 2}},
+		{Point: Coordinate[float32]{-2, 0}, Delta: Coordinate[float32]{4, 2}},
+		{Point: Coordinate[float32]{7, 20}, Delta: Coordinate[float32]{2, 2}},
+	}
+	return query, [6]*Coordinate[float32]{{14, 4}, {20, -25}, {-40, -40}, {inf, 4}, {nan, 4}, {subnormal, -25}}
+}
+
+func TestFlatIntersects(t *testing.T) {
+	tree := getIdealTree()
+	flat := tree.Freeze()
+	query, delta := sweepCases()
+
+	for in, q := range query {
+		expected := map[*Orthotope[float32]]float32{}
+		iter := tree.Iterator()
+		for r, d := iter.Intersects(q, delta[in]); r != nil; r, d = iter.Intersects(q, delta[in]) {
+			expected[r] = d
+		}
+		flatIter := flat.Iterator()
+		for r, d := flatIter.Intersects(q, delta[in]); r != nil; r, d = flatIter.Intersects(q, delta[in]) {
+			if e, ok := expected[r]; !ok || e != d {
+				t.Errorf("Intersection for %v returned unexpected value: %v at %v\n", q.String(), r.String(), d)
+			}
+			delete(expected, r)
+		}
+		for r := range expected {
+			t.Errorf("Intersection for %v did not return %v\n", q.String(), r.String())
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := BinnedSAHBVH[*Orthotope[int32], int32](orths, 4)
+	iter := tree.Iterator()
+	flatIter := tree.Freeze().Iterator()
+
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		point := Coordinate[int32]{r.Int31n(1200) - 100, r.Int31n(1200) - 100, r.Int31n(1200) - 100}
+		var expected int32 = -1
+		for _, orth := range orths {
+			if d := volDistanceSq(point, orth, nil); expected < 0 || d < expected {
+				expected = d
+			}
+		}
+
+		nearest, d := iter.Nearest(point)
+		if d != expected || volDistanceSq(point, nearest, nil) != d {
+			t.Errorf("Nearest to %v returned distance %d; expected %d\n", point, d, expected)
+		}
+		flatNearest, flatD := flatIter.Nearest(point)
+		if flatNearest != nearest || flatD != d {
+			t.Errorf("FlatBVH nearest to %v returned %v; expected %v\n", point, flatNearest.String(), nearest.String())
+		}
+	}
+}
+
+func TestNearestLarge(t *testing.T) {
+	// The squared distance to far, 65536 along each axis, wraps around to 0 for int32.
+	near := &Orthotope[int32]{Point: Coordinate[int32]{40000, 0, 0}, Delta: Coordinate[int32]{1, 1, 1}}
+	far := &Orthotope[int32]{Point: Coordinate[int32]{65536, 65536, 65536}, Delta: Coordinate[int32]{1, 1, 1}}
+	tree := TopDownBVH[*Orthotope[int32], int32]([]*Orthotope[int32]{near, far})
+	flat := tree.Freeze()
+	mapped, err := OpenMapped[int32](writeMapped(t, flat, flat.vols), true)
+	if err != nil {
+		t.Fatalf("Unable to open: %v", err)
+	}
+	defer mapped.Close()
+
+	if nearest, d := tree.Iterator().Nearest(Coordinate[int32]{}); nearest != near || d != 40000*40000 {
+		t.Errorf("Nearest returned %v at %d, expected %v", nearest, d, near.String())
+	}
+	if nearest, d := flat.Iterator().Nearest(Coordinate[int32]{}); nearest != near || d != 40000*40000 {
+		t.Errorf("FlatBVH nearest returned %v at %d, expected %v", nearest, d, near.String())
+	}
+	if id, d := mapped.Iterator().Nearest(Coordinate[int32]{}); flat.vols[id] != near || d != 40000*40000 {
+		t.Errorf("MappedBVH nearest returned %d at %d, expected %v", id, d, near.String())
+	}
+	if d := volDistanceSq(Coordinate[int32]{}, far, nil); d != math.MaxInt32 {
+		t.Errorf("Expected the distance to %v to saturate, got %d", far.String(), d)
+	}
+}
+
+func TestFlatConcurrentQuery(t *testing.T) {
+	orths := randomOrths(2000)
+	flat := TopDownBVH[*Orthotope[int32], int32](orths).Freeze()
+	q := &Orthotope[int32]{Point: Coordinate[int32]{200, 200, 200}, Delta: Coordinate[int32]{300, 300, 300}}
+
+	count := func() int {
+		iter := flat.Iterator()
+		found := 0
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+			found++
+		}
+		return found
+	}
+	expected := count()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if found := count(); found != expected {
+					t.Errorf("Concurrent query found %d volumes; expected %d", found, expected)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkQuery(b *testing.B) {
+	orths := randomOrths(50000)
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Iterator()
+	queries := randomOrths(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+		}
+	}
+}
+
+func BenchmarkFlatQuery(b *testing.B) {
+	orths := randomOrths(50000)
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Freeze().Iterator()
+	queries := randomOrths(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+		}
+	}
+}
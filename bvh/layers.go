@@ -0,0 +1,80 @@
+package collision
+
+const (
+	// DefaultLayer is the category of volumes added without layers.
+	DefaultLayer uint64 = 1
+	// AllLayers includes every volume in a query, even those without layers.
+	AllLayers uint64 = ^uint64(0)
+)
+
+// inLayers returns true iff the volume (or one below it) is in one of the include layers and none of the exclude.
+func (b *BVol[T, E]) inLayers(include, exclude uint64) bool {
+	return (include == AllLayers || b.layers()&include != 0) && b.shared()&exclude == 0
+}
+
+// layers returns the union of the layers of the leaves at or below the volume. Volumes without options are in the
+// DefaultLayer, unless they are empty.
+func (b *BVol[T, E]) layers() uint64 {
+	if b.opt != nil {
+		return b.opt.layers
+	} else if b.count == 0 {
+		return 0
+	}
+	return DefaultLayer
+}
+
+// shared returns the intersection of the layers of the leaves at or below the volume.
+func (b *BVol[T, E]) shared() uint64 {
+	if b.opt != nil {
+		return b.opt.shared
+	}
+	return b.layers()
+}
+
+// setLayers changes the layers of the volume, which creates its options unless they are the default. Set the count
+// first, since it decides the default.
+func (b *BVol[T, E]) setLayers(layers, shared uint64) {
+	if b.opt == nil && layers == b.layers() && shared == layers {
+		return
+	}
+	opt := b.options()
+	opt.layers, opt.shared = layers, shared
+}
+
+// relayer recalculates the layers based on children.
+func (b *BVol[T, E]) relayer() {
+	b.setLayers(b.desc[0].layers()|b.desc[1].layers(), b.desc[0].shared()&b.desc[1].shared())
+}
+
+// AddLayers adds an orth to a Bounding Volume Hierarchy in the given layers. Only add to root volume.
+func (b *BVol[T, E]) AddLayers(orth T, layers uint64) bool {
+	s := b.Iterator()
+	return s.AddLayers(orth, layers)
+}
+
+// SetLayers moves an orth already in the BVH to the given layers. Returns false if it was not found.
+func (s *orthStack[T, E]) SetLayers(o T, layers uint64) bool {
+	s.Reset()
+	bvol := s.path(o)
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
+		return false
+	}
+	s.own()
+	bvol, _ = s.pop()
+	bvol.setLayers(layers, layers)
+	for s.HasNext() {
+		bvol, _ = s.pop()
+		bvol.relayer()
+	}
+	return true
+}
+
+// Layers returns the layers of an orth in the BVH, or 0 if it was not found.
+func (s *orthStack[T, E]) Layers(o T) uint64 {
+	s.Reset()
+	bvol := s.path(o)
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
+		return 0
+	}
+	return bvol.layers()
+}
@@ -0,0 +1,152 @@
+package collision
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkLayers verifies that each volume has the union and intersection of the layers below it.
+func checkLayers[T VolumeType[E], E Number](t *testing.T, tree *BVol[T, E]) {
+	iter := tree.Iterator()
+	for iter.HasNext() {
+		next := iter.Next()
+		if next.depth == 0 {
+			if next.layers() != next.shared() {
+				t.Errorf("Leaf %v has layers %b, shared %b", next.vol.String(), next.layers(), next.shared())
+			}
+			continue
+		}
+		if next.layers() != next.desc[0].layers()|next.desc[1].layers() ||
+			next.shared() != next.desc[0].shared()&next.desc[1].shared() {
+			t.Errorf("Volume %v has layers %b, shared %b", next.vol.String(), next.layers(), next.shared())
+		}
+	}
+}
+
+func TestLayers(t *testing.T) {
+	orths := randomOrths(1000)
+	layers := map[*Orthotope[int32]]uint64{}
+	r := rand.New(rand.NewSource(3))
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := NewArena[*Orthotope[int32], int32](0).Iterator(tree)
+	for _, orth := range orths {
+		layers[orth] = 1 << r.Intn(4)
+		if !iter.AddLayers(orth, layers[orth]) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths[:300] {
+		iter.Remove(orth)
+		delete(layers, orth)
+	}
+	for _, orth := range orths[300:400] {
+		layers[orth] = 0b1100
+		if !iter.SetLayers(orth, layers[orth]) || iter.Layers(orth) != layers[orth] {
+			t.Errorf("Unable to set the layers of %v\n", orth.String())
+		}
+	}
+	checkLayers(t, tree)
+	checkBounds(t, tree)
+
+	queries := randomOrths(100)
+	delta := &Coordinate[int32]{50, -20, 10}
+	for _, q := range queries {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+		for _, mask := range [][2]uint64{{0b0001, 0}, {0b0110, 0b0100}, {AllLayers, 0b1000}, {0b10000, 0}} {
+			include, exclude := mask[0], mask[1]
+			expected := map[*Orthotope[int32]]bool{}
+			iter.Reset()
+			for found := iter.Query(q); found != nil; found = iter.Query(q) {
+				if layers[found]&include != 0 && layers[found]&exclude == 0 {
+					expected[found] = true
+				}
+			}
+			iter.Reset()
+			for found := iter.QueryLayers(q, include, exclude); found != nil; found = iter.QueryLayers(q, include, exclude) {
+				if !expected[found] {
+					t.Errorf("QueryLayers(%b, %b) returned %v in layers %b", include, exclude, found.String(),
+						layers[found])
+				}
+				delete(expected, found)
+			}
+			for found := range expected {
+				t.Errorf("QueryLayers(%b, %b) did not return %v", include, exclude, found.String())
+			}
+
+			iter.Reset()
+			for found, _ := iter.IntersectsLayers(q, delta, include, exclude); found != nil; found, _ =
+				iter.IntersectsLayers(q, delta, include, exclude) {
+				if layers[found]&include == 0 || layers[found]&exclude != 0 {
+					t.Errorf("IntersectsLayers(%b, %b) returned %v in layers %b", include, exclude, found.String(),
+						layers[found])
+				}
+			}
+		}
+	}
+}
+
+func TestLayersSingle(t *testing.T) {
+	orth := randomOrths(1)[0]
+	tree := &BVol[*Orthotope[int32], int32]{}
+	tree.AddLayers(orth, 0b10)
+	iter := tree.Iterator()
+	if found := iter.QueryLayers(orth, 0b01, 0); found != nil {
+		t.Errorf("Querying the wrong layer returned %v", found.String())
+	}
+	iter.Reset()
+	if found := iter.QueryLayers(orth, 0b11, 0); found != orth {
+		t.Errorf("Querying the layer of %v returned %v", orth.String(), found)
+	}
+	if tree.Remove(orth); tree.layers() != 0 || tree.shared() != 0 {
+		t.Errorf("Empty tree has layers %b, shared %b", tree.layers(), tree.shared())
+	}
+}
+
+func TestLayersBuilders(t *testing.T) {
+	orths := randomOrths(200)
+	for _, tree := range []*BVol[*Orthotope[int32], int32]{TopDownBVH[*Orthotope[int32], int32](orths),
+		BinnedSAHBVH[*Orthotope[int32], int32](orths, 4), LinearBVH[*Orthotope[int32], int32](orths)} {
+		checkLayers(t, tree)
+		if tree.layers() != DefaultLayer || tree.shared() != DefaultLayer {
+			t.Errorf("Expected volumes to be built in the DefaultLayer, got %b", tree.layers())
+		}
+	}
+}
+
+func TestLayersOptions(t *testing.T) {
+	// Volumes in the DefaultLayer with priority 0 need no options.
+	orths := randomOrths(500)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := tree.Iterator()
+	for _, orth := range orths {
+		iter.Add(orth)
+	}
+	for _, orth := range orths[:100] {
+		iter.Remove(orth)
+	}
+	options := func() int {
+		count := 0
+		iter.Reset()
+		for iter.HasNext() {
+			if iter.Next().opt != nil {
+				count++
+			}
+		}
+		return count
+	}
+	if count := options(); count != 0 {
+		t.Errorf("Expected no volumes with options, got %d", count)
+	}
+
+	// Only the path to a leaf in other layers needs options.
+	iter.SetLayers(orths[200], 2)
+	if count, depth := options(), int(tree.GetDepth()); count > depth+1 {
+		t.Errorf("Expected at most %d volumes with options, got %d", depth+1, count)
+	}
+	checkLayers(t, tree)
+	if iter.Layers(orths[200]) != 2 || iter.Layers(orths[300]) != DefaultLayer {
+		t.Errorf("Unexpected layers %b and %b", iter.Layers(orths[200]), iter.Layers(orths[300]))
+	}
+}
@@ -0,0 +1,268 @@
+package collision
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// MortonBits is the number of bits per dimension used for the Morton codes in LinearBVH.
+const MortonBits int = 63 / math32.DIMENSIONS
+
+// LinearBVH quickly creates a BVH by sorting volumes along a Morton (Z-order) curve through their centers and
+// splitting at the highest differing bit. Construction takes O(n) time after an O(n) radix sort. Since clustered
+// volumes split unevenly, the tree is then rebalanced such that it can be used with Add and Remove. The tree is
+// usually of lower quality than TopDownBVH or BinnedSAHBVH; see OptimizeTreelets.
+func LinearBVH[T math32.VolumeType[E], E math32.Number](orths []T) *BVol[T, E] {
+	if len(orths) == 0 {
+		return &BVol[T, E]{}
+	}
+
+	codes := make([]uint64, len(orths))
+	low, high := centerBounds[T, E](orths)
+	for i, orth := range orths {
+		codes[i] = mortonCode(orth, low, high)
+	}
+
+	sorted := make([]T, len(orths))
+	copy(sorted, orths)
+	radixSort(codes, sorted)
+	tree := emitLinear(codes, sorted)
+	tree.rebalance()
+	return tree
+}
+
+// mortonCode interleaves the quantized center of vol within [low, high].
+func mortonCode[E math32.Number](vol math32.VolumeType[E], low, high math32.Coordinate[E]) uint64 {
+	var point math32.Coordinate[E]
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		point[d] = center[E](vol, d)
+	}
+	return mortonPoint(point, low, high)
+}
+
+// mortonPoint interleaves the quantized point within [low, high].
+func mortonPoint[E math32.Number](point, low, high math32.Coordinate[E]) uint64 {
+	scale := float64(uint64(1)<<MortonBits - 1)
+	var code uint64
+	var quantized [math32.DIMENSIONS]uint64
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		if extent := high[d] - low[d]; extent > 0 {
+			quantized[d] = uint64(float64(point[d]-low[d]) / float64(extent) * scale)
+		}
+	}
+	for b := MortonBits - 1; b >= 0; b-- {
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			code = code<<1 | (quantized[d]>>uint(b))&1
+		}
+	}
+	return code
+}
+
+// radixSort sorts codes (and orths alongside them) one byte at a time, least significant byte first.
+func radixSort[T any](codes []uint64, orths []T) {
+	codeBuf := make([]uint64, len(codes))
+	orthBuf := make([]T, len(orths))
+	for shift := uint(0); shift < uint(MortonBits*math32.DIMENSIONS); shift += 8 {
+		var counts [257]int
+		for _, code := range codes {
+			counts[(code>>shift)&0xff+1]++
+		}
+		for i := 1; i < len(counts); i++ {
+			counts[i] += counts[i-1]
+		}
+		for i, code := range codes {
+			digit := (code >> shift) & 0xff
+			codeBuf[counts[digit]] = code
+			orthBuf[counts[digit]] = orths[i]
+			counts[digit]++
+		}
+		codes, codeBuf = codeBuf, codes
+		orths, orthBuf = orthBuf, orths
+	}
+	// An odd number of passes leaves the sorted results in the buffers.
+	if (MortonBits*math32.DIMENSIONS+7)/8%2 == 1 {
+		copy(codeBuf, codes)
+		copy(orthBuf, orths)
+	}
+}
+
+// emitLinear recursively builds the hierarchy over sorted codes.
+func emitLinear[T math32.VolumeType[E], E math32.Number](codes []uint64, orths []T) *BVol[T, E] {
+	if len(orths) == 1 {
+		return newLeaf[T, E](orths[0], DefaultLayer)
+	}
+	mid := splitCodes(codes)
+	return joinBVol(emitLinear[T, E](codes[:mid], orths[:mid]), emitLinear[T, E](codes[mid:], orths[mid:]))
+}
+
+// splitCodes returns the first index whose code differs from the first code at the highest differing bit. Identical
+// codes are split in half.
+func splitCodes(codes []uint64) int {
+	first, last := codes[0], codes[len(codes)-1]
+	if first == last {
+		return len(codes) / 2
+	}
+	prefix := bits.LeadingZeros64(first ^ last)
+
+	// Binary search for the last code that shares more than prefix bits with the first.
+	split := 0
+	for step := len(codes); step > 1; {
+		step = (step + 1) / 2
+		if next := split + step; next < len(codes) && bits.LeadingZeros64(first^codes[next]) > prefix {
+			split = next
+		}
+	}
+	return split + 1
+}
+
+// OptimizeTreelets improves the quality of a tree (eg. from LinearBVH) by restructuring treelets from the leaves up.
+// The treelet of a volume is formed by repeatedly replacing the largest of the subtrees below it with its children,
+// until there are TreeletLeaves subtrees. These are then rearranged into the balanced hierarchy with the lowest score,
+// without changing the depth of the volume, such that the score of the tree never increases. It does not change which
+// volumes are stored.
+func (b *BVol[T, E]) OptimizeTreelets() {
+	b.rebalance()
+	if b.depth < 2 {
+		return
+	}
+	t := &treelet[T, E]{
+		bounds: make([]T, 1<<TreeletLeaves),
+		cost:   make([][treeletHeights]treeletCost, 1<<TreeletLeaves),
+	}
+	for i := range t.bounds {
+		t.bounds[i] = b.vol.New().(T)
+	}
+	t.optimize(b)
+}
+
+// TreeletLeaves is the number of subtrees that OptimizeTreelets rearranges at a time.
+const TreeletLeaves int = 7
+
+// treeletHeights bounds the height of a treelet above its shallowest subtree.
+const treeletHeights int = 2 * TreeletLeaves
+
+// treelet holds the space used to restructure treelets, reused for every volume.
+type treelet[T math32.VolumeType[E], E math32.Number] struct {
+	leaves   []*BVol[T, E]
+	internal []*BVol[T, E]
+	// bounds and cost are indexed by subsets of leaves, and cost also by the height of each arrangement of the subset.
+	bounds []T
+	cost   [][treeletHeights]treeletCost
+}
+
+// treeletCost is the lowest total score of the volumes arranging a subset of a treelet with a given height, and the
+// split of the subset into the children of its volume that achieves it.
+type treeletCost struct {
+	score   float64
+	first   int
+	heights [2]int
+}
+
+// optimize restructures the treelets of the volumes below bvol, children first.
+func (t *treelet[T, E]) optimize(bvol *BVol[T, E]) {
+	if bvol.depth == 0 {
+		return
+	}
+	t.optimize(bvol.desc[0])
+	t.optimize(bvol.desc[1])
+	t.restructure(bvol)
+}
+
+// restructure forms the treelet of bvol and replaces it with its best arrangement.
+func (t *treelet[T, E]) restructure(bvol *BVol[T, E]) {
+	t.leaves = append(t.leaves[:0], bvol.desc[0], bvol.desc[1])
+	t.internal = t.internal[:0]
+	for len(t.leaves) < TreeletLeaves {
+		largest := -1
+		for i, leaf := range t.leaves {
+			if leaf.depth > 0 && (largest < 0 || leaf.vol.Score() > t.leaves[largest].vol.Score()) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			break
+		}
+		expand := t.leaves[largest]
+		t.internal = append(t.internal, expand)
+		t.leaves[largest] = expand.desc[0]
+		t.leaves = append(t.leaves, expand.desc[1])
+	}
+	if len(t.leaves) < 3 {
+		return
+	}
+
+	shallowest := t.leaves[0].depth
+	for _, leaf := range t.leaves {
+		shallowest = math32.Int32Min(shallowest, leaf.depth)
+	}
+	height := int(bvol.depth - shallowest)
+	if height >= treeletHeights {
+		return
+	}
+
+	// Subsets are numbered such that every subset comes after the subsets it contains.
+	all := 1<<len(t.leaves) - 1
+	for subset := 1; subset <= all; subset++ {
+		cost := &t.cost[subset]
+		for h := range cost {
+			cost[h] = treeletCost{score: math.Inf(1)}
+		}
+		lowest := subset & -subset
+		if subset == lowest {
+			cost[t.leaves[bits.TrailingZeros(uint(subset))].depth-shallowest].score = 0
+			continue
+		}
+		minBoundsPair(t.bounds[subset], t.boundsOf(lowest), t.boundsOf(subset^lowest))
+		score := float64(t.bounds[subset].Score())
+
+		// The first child holds the lowest leaf, so that each split is only considered once.
+		for first := (subset - 1) & subset; first > 0; first = (first - 1) & subset {
+			if first&lowest == 0 {
+				continue
+			}
+			second := subset ^ first
+			for h0 := 0; h0 < height; h0++ {
+				if math.IsInf(t.cost[first][h0].score, 1) {
+					continue
+				}
+				// Keep the children balanced, as Add and Remove expect.
+				for h1 := max(h0-1, 0); h1 <= h0+1 && h1 < height; h1++ {
+					total := t.cost[first][h0].score + t.cost[second][h1].score + score
+					if h := max(h0, h1) + 1; total < cost[h].score {
+						cost[h] = treeletCost{score: total, first: first, heights: [2]int{h0, h1}}
+					}
+				}
+			}
+		}
+	}
+	if !math.IsInf(t.cost[all][height].score, 1) {
+		t.build(bvol, all, height)
+	}
+}
+
+// boundsOf returns the bounds of the given subset of leaves.
+func (t *treelet[T, E]) boundsOf(subset int) T {
+	if subset&(subset-1) == 0 {
+		return t.leaves[bits.TrailingZeros(uint(subset))].vol
+	}
+	return t.bounds[subset]
+}
+
+// build arranges the subset of leaves below bvol with the lowest score for the given height, taking the volumes
+// between them from internal.
+func (t *treelet[T, E]) build(bvol *BVol[T, E], subset int, height int) {
+	cost := t.cost[subset][height]
+	for i, child := range [2]int{cost.first, subset ^ cost.first} {
+		if child&(child-1) == 0 {
+			bvol.desc[i] = t.leaves[bits.TrailingZeros(uint(child))]
+			continue
+		}
+		bvol.desc[i] = t.internal[len(t.internal)-1]
+		t.internal = t.internal[:len(t.internal)-1]
+		t.build(bvol.desc[i], child, cost.heights[i])
+	}
+	bvol.redepth()
+	bvol.minBound()
+}
@@ -0,0 +1,187 @@
+package collision
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/briannoyama/bvh/math32"
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestLinearBVH(t *testing.T) {
+	orths := make([]*math32.Orthotope[float32], len(leaf))
+	copy(orths, leaf[:])
+	tree := LinearBVH[*math32.Orthotope[float32], float32](orths)
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+
+	iter := tree.Iterator()
+	for _, orth := range leaf {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find: %v\n", orth.String())
+		}
+	}
+
+	score := tree.Score()
+	tree.OptimizeTreelets()
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if tree.Score() > score {
+		t.Errorf("OptimizeTreelets increased the score from %v to %v", score, tree.Score())
+	}
+}
+
+func TestRadixSort(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	codes := make([]uint64, 1000)
+	orths := make([]int, len(codes))
+	for i := range codes {
+		codes[i] = r.Uint64() >> 1
+		orths[i] = i
+	}
+	original := make([]uint64, len(codes))
+	copy(original, codes)
+
+	radixSort(codes, orths)
+	if !sort.SliceIsSorted(codes, func(i, j int) bool { return codes[i] < codes[j] }) {
+		t.Errorf("Codes were not sorted.")
+	}
+	for i, o := range orths {
+		if original[o] != codes[i] {
+			t.Errorf("Volume %d was not sorted alongside its code.", o)
+		}
+	}
+}
+
+func TestSplitCodes(t *testing.T) {
+	cases := []struct {
+		codes []uint64
+		want  int
+	}{
+		{[]uint64{0, 1}, 1},
+		{[]uint64{1, 1, 1, 1}, 2},
+		{[]uint64{0b000, 0b001, 0b010, 0b100, 0b101}, 3},
+		{[]uint64{0b100, 0b101, 0b110, 0b111}, 2},
+	}
+	for _, c := range cases {
+		if got := splitCodes(c.codes); got != c.want {
+			t.Errorf("splitCodes(%b) = %d, expected %d", c.codes, got, c.want)
+		}
+	}
+}
+
+func TestLinearBVHAddRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	orths := make([]*Orthotope[float64], 600)
+	for i := range orths {
+		orths[i] = &Orthotope[float64]{
+			Point: Coordinate[float64]{float64(r.Intn(1000)), float64(r.Intn(1000)), float64(r.Intn(1000))},
+			Delta: Coordinate[float64]{float64(r.Intn(20)), float64(r.Intn(20)), float64(r.Intn(20))},
+		}
+	}
+	tree := LinearBVH[*Orthotope[float64], float64](orths[:500])
+	tree.OptimizeTreelets()
+	for _, orth := range orths[500:] {
+		if !tree.Add(orth) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths[:300] {
+		if !tree.Remove(orth) {
+			t.Errorf("Unable to remove: %v\n", orth.String())
+		}
+	}
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+
+	iter := tree.Iterator()
+	for i, orth := range orths {
+		if iter.Contains(orth) != (i >= 300) {
+			t.Errorf("Unexpected membership for %v\n", orth.String())
+		}
+	}
+}
+
+func TestLinearBVHBalance(t *testing.T) {
+	// Clustered volumes share the high bits of their codes, so the splits are uneven.
+	orths := randomOrths(3000)
+	for _, orth := range orths[:1000] {
+		orth.Point = [DIMENSIONS]int32{orth.Point[0] % 10, orth.Point[1] % 10, orth.Point[2] % 10}
+	}
+	tree := LinearBVH[*Orthotope[int32], int32](orths)
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if tree.count != int32(len(orths)) || tree.GetDepth() > 17 {
+		t.Errorf("Expected %d volumes within depth 17, got %d with depth %d", len(orths), tree.count,
+			tree.GetDepth())
+	}
+	iter := tree.Iterator()
+	for _, orth := range orths {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find: %v\n", orth.String())
+		}
+	}
+}
+
+func TestOptimizeTreeletsBalance(t *testing.T) {
+	// A chain of volumes, each joined with the branch of all the volumes before it.
+	orths := randomOrths(100)
+	tree := newLeaf[*Orthotope[int32], int32](orths[0], DefaultLayer)
+	for _, orth := range orths[1:] {
+		tree = joinBVol(tree, newLeaf[*Orthotope[int32], int32](orth, DefaultLayer))
+	}
+	tree.OptimizeTreelets()
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if tree.count != int32(len(orths)) || tree.GetDepth() > 9 {
+		t.Errorf("Expected %d volumes within depth 9, got %d with depth %d", len(orths), tree.count, tree.GetDepth())
+	}
+}
+
+func TestOptimizeTreelets(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := LinearBVH[*Orthotope[int32], int32](orths)
+	score, depth := tree.Score(), tree.GetDepth()
+	tree.OptimizeTreelets()
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if tree.Score() >= score || tree.GetDepth() != depth {
+		t.Errorf("Expected a score below %v with depth %d, got %v with depth %d", score, depth, tree.Score(),
+			tree.GetDepth())
+	}
+	iter := tree.Iterator()
+	for _, orth := range orths {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find: %v\n", orth.String())
+		}
+	}
+	if tree.count != int32(len(orths)) {
+		t.Errorf("Expected %d volumes, got %d", len(orths), tree.count)
+	}
+}
+
+func BenchmarkLinearBVH(b *testing.B) {
+	r := rand.New(rand.NewSource(7))
+	orths := make([]*Orthotope[float32], 10000)
+	for i := range orths {
+		orths[i] = &Orthotope[float32]{
+			Point: Coordinate[float32]{r.Float32() * 1000, r.Float32() * 1000, r.Float32() * 1000},
+			Delta: Coordinate[float32]{r.Float32() * 20, r.Float32() * 20, r.Float32() * 20},
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		LinearBVH[*Orthotope[float32], float32](orths)
+	}
+}
+
+func BenchmarkOptimizeTreelets(b *testing.B) {
+	orths := randomOrths(10000)
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tree := LinearBVH[*Orthotope[int32], int32](orths)
+		b.StartTimer()
+		tree.OptimizeTreelets()
+	}
+}
@@ -0,0 +1,346 @@
+package collision
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"unsafe"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// The file format of a MappedBVH is a header of mappedHeader bytes:
+//
+//	magic      [8]byte  "BVHFLAT\x00"
+//	version    uint32
+//	dimensions uint32   math32.DIMENSIONS
+//	kind       uint32   the numeric type (see numericKind)
+//	nodeSize   uint32   bytes per node
+//	nodes      uint64
+//	leaves     uint64
+//	checksum   uint64   CRC-64 (ECMA) of everything after the header
+//
+// followed by the nodes of a FlatBVH, in the layout of flatNode, and then the uint64 ID of each leaf. Values are
+// little endian.
+const (
+	mappedMagic   = "BVHFLAT\x00"
+	mappedVersion = 1
+	mappedHeader  = 64
+)
+
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// numericKind identifies the numeric type of a file, since the size of a value does not tell floats from integers,
+// nor integers from fixed point values.
+func numericKind[E math32.Number]() uint32 {
+	switch math32.KindOf[E]() {
+	case math32.KindFloat32:
+		return 1
+	case math32.KindFloat64:
+		return 2
+	case math32.KindInt32:
+		return 3
+	case math32.KindInt64:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// Write the FlatBVH in the format read by OpenMapped. id gives the ID stored for each volume, which queries of the
+// MappedBVH return; when id is nil, the index of the volume in depth first order is stored instead.
+func (f *FlatBVH[T, E]) Write(w io.Writer, id func(T) uint64) error {
+	// Encode the body twice, first for the checksum in the header and then to write it.
+	crc := crc64.New(crcTable)
+	f.encode(crc, id)
+	header := make([]byte, mappedHeader)
+	copy(header, mappedMagic)
+	binary.LittleEndian.PutUint32(header[8:], mappedVersion)
+	binary.LittleEndian.PutUint32(header[12:], uint32(math32.DIMENSIONS))
+	binary.LittleEndian.PutUint32(header[16:], numericKind[E]())
+	binary.LittleEndian.PutUint32(header[20:], uint32(unsafe.Sizeof(flatNode[E]{})))
+	binary.LittleEndian.PutUint64(header[24:], uint64(len(f.nodes)))
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(f.vols)))
+	binary.LittleEndian.PutUint64(header[40:], crc.Sum64())
+
+	buffered := bufio.NewWriter(w)
+	buffered.Write(header)
+	f.encode(buffered, id)
+	return buffered.Flush()
+}
+
+// encode writes the nodes and IDs of the FlatBVH. Errors are left to the caller of Flush.
+func (f *FlatBVH[T, E]) encode(w io.Writer, id func(T) uint64) {
+	var buf []byte
+	kind := math32.KindOf[E]()
+	for _, node := range f.nodes {
+		buf = buf[:0]
+		for _, c := range [2]math32.Coordinate[E]{node.min, node.max} {
+			for _, value := range c {
+				buf = appendFixed(buf, kind, value)
+			}
+		}
+		for _, value := range [4]int32{node.second, node.skip, node.leaf, node.depth} {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(value))
+		}
+		w.Write(buf)
+	}
+	for index, vol := range f.vols {
+		buf = buf[:0]
+		if id == nil {
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(index))
+		} else {
+			buf = binary.LittleEndian.AppendUint64(buf, id(vol))
+		}
+		w.Write(buf)
+	}
+}
+
+// appendFixed appends value in the little endian layout of E. kind is the kind of E, found once rather than for
+// every value.
+func appendFixed[E math32.Number](buf []byte, kind math32.Kind, value E) []byte {
+	switch kind {
+	case math32.KindFloat32:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(float32(value)))
+	case math32.KindFloat64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(float64(value)))
+	case math32.KindInt32:
+		return binary.LittleEndian.AppendUint32(buf, uint32(int32(value)))
+	default:
+		return binary.LittleEndian.AppendUint64(buf, uint64(int64(value)))
+	}
+}
+
+// readFixed decodes a value written by appendFixed from the start of data.
+func readFixed[E math32.Number](data []byte, kind math32.Kind) E {
+	switch kind {
+	case math32.KindFloat32:
+		return E(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	case math32.KindFloat64:
+		return E(math.Float64frombits(binary.LittleEndian.Uint64(data)))
+	case math32.KindInt32:
+		return E(int32(binary.LittleEndian.Uint32(data)))
+	default:
+		return E(int64(binary.LittleEndian.Uint64(data)))
+	}
+}
+
+// MappedBVH is a FlatBVH read from a file written by FlatBVH.Write. The file is memory mapped (where supported) and
+// queried in place, so opening it does not read or decode the nodes. Leaves are only stored by their bounds (see
+// GetPoint and GetDelta) and ID, so queries test the bounds of leaves, which is exact for orthotopes. A MappedBVH may
+// be shared across goroutines, as long as each goroutine uses its own iterator.
+type MappedBVH[E math32.Number] struct {
+	data  []byte
+	close func() error
+	nodes []flatNode[E]
+	ids   []uint64
+}
+
+// OpenMapped opens a file written by FlatBVH.Write with values of type E. Returns ErrFormat if the file is not in the
+// format, or was written with other dimensions or another numeric type. If verify is true the checksum and the links
+// between nodes are checked, which reads the whole file, and ErrChecksum or ErrFormat is returned if they do not
+// match. Queries of a corrupt file that was not verified may panic. Call Close when done.
+func OpenMapped[E math32.Number](path string, verify bool) (*MappedBVH[E], error) {
+	data, closer, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := readMapped[E](data, verify)
+	if err != nil {
+		closer()
+		return nil, err
+	}
+	m.close = closer
+	return m, nil
+}
+
+// readMapped checks the header of data, then uses the nodes and IDs in place on little endian platforms. On other
+// platforms they are decoded.
+func readMapped[E math32.Number](data []byte, verify bool) (*MappedBVH[E], error) {
+	if len(data) < mappedHeader || string(data[:8]) != mappedMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrFormat)
+	}
+	version := binary.LittleEndian.Uint32(data[8:])
+	dimensions, kind := binary.LittleEndian.Uint32(data[12:]), binary.LittleEndian.Uint32(data[16:])
+	nodeSize := binary.LittleEndian.Uint32(data[20:])
+	nodes, leaves := binary.LittleEndian.Uint64(data[24:]), binary.LittleEndian.Uint64(data[32:])
+	if version != mappedVersion || dimensions != uint32(math32.DIMENSIONS) || kind != numericKind[E]() ||
+		nodeSize != uint32(unsafe.Sizeof(flatNode[E]{})) {
+		return nil, fmt.Errorf("%w: version %d, %d dimensions of kind %d", ErrFormat, version, dimensions, kind)
+	}
+	body := data[mappedHeader:]
+	if size := uint64(len(body)); nodes > size/uint64(nodeSize) || leaves > size/8 ||
+		size != nodes*uint64(nodeSize)+leaves*8 {
+		return nil, fmt.Errorf("%w: %d bytes for %d nodes and %d leaves", ErrFormat, len(body), nodes, leaves)
+	}
+	if verify && crc64.Checksum(body, crcTable) != binary.LittleEndian.Uint64(data[40:]) {
+		return nil, ErrChecksum
+	}
+
+	m := &MappedBVH[E]{data: data}
+	idData := body[nodes*uint64(nodeSize):]
+	probe := uint16(1)
+	if *(*byte)(unsafe.Pointer(&probe)) == 1 {
+		if nodes > 0 {
+			m.nodes = unsafe.Slice((*flatNode[E])(unsafe.Pointer(&body[0])), nodes)
+		}
+		if leaves > 0 {
+			m.ids = unsafe.Slice((*uint64)(unsafe.Pointer(&idData[0])), leaves)
+		}
+	} else {
+		m.decode(body, int(nodes), int(leaves))
+	}
+	for index := 0; verify && index < len(m.nodes); index++ {
+		// Check the links between nodes, so that queries of a corrupt file do not panic.
+		node := &m.nodes[index]
+		if node.skip <= int32(index) || int(node.skip) > len(m.nodes) || int(node.leaf) >= len(m.ids) ||
+			(node.leaf < 0 && (node.second <= int32(index)+1 || node.second >= node.skip)) {
+			return nil, fmt.Errorf("%w: node %d", ErrFormat, index)
+		}
+	}
+	return m, nil
+}
+
+// decode copies the nodes and IDs from the little endian body.
+func (m *MappedBVH[E]) decode(body []byte, nodes, leaves int) {
+	size, kind := int(unsafe.Sizeof(E(0))), math32.KindOf[E]()
+	m.nodes = make([]flatNode[E], nodes)
+	for index := range m.nodes {
+		node := &m.nodes[index]
+		for d := 0; d < 2*math32.DIMENSIONS; d++ {
+			value := readFixed[E](body, kind)
+			if d < math32.DIMENSIONS {
+				node.min[d] = value
+			} else {
+				node.max[d-math32.DIMENSIONS] = value
+			}
+			body = body[size:]
+		}
+		for _, field := range []*int32{&node.second, &node.skip, &node.leaf, &node.depth} {
+			*field = int32(binary.LittleEndian.Uint32(body))
+			body = body[4:]
+		}
+	}
+	m.ids = make([]uint64, leaves)
+	for index := range m.ids {
+		m.ids[index] = binary.LittleEndian.Uint64(body[8*index:])
+	}
+}
+
+// Close unmaps the file. The MappedBVH and its iterators must not be used afterwards.
+func (m *MappedBVH[E]) Close() error {
+	m.nodes, m.ids = nil, nil
+	return m.close()
+}
+
+// GetDepth of the root volume, ie. the height of the tree.
+func (m *MappedBVH[E]) GetDepth() int32 {
+	if len(m.nodes) == 0 {
+		return 0
+	}
+	return m.nodes[0].depth
+}
+
+// Len returns the number of volumes stored.
+func (m *MappedBVH[E]) Len() int {
+	return len(m.ids)
+}
+
+// Iterator for querying the MappedBVH. Iterators are not thread-safe; create one per goroutine.
+func (m *MappedBVH[E]) Iterator() *mappedStack[E] {
+	return &mappedStack[E]{mapped: m}
+}
+
+// mappedStack provides the query methods of flatStack for a MappedBVH, returning the IDs of leaves.
+type mappedStack[E math32.Number] struct {
+	mapped *MappedBVH[E]
+	next   int32
+	stack  []int32
+}
+
+// Reset the iterator to the root of the MappedBVH.
+func (s *mappedStack[E]) Reset() {
+	s.next = 0
+	s.stack = s.stack[:0]
+}
+
+// Query looks for leaves whose bounds overlap those of o, returning the ID of one at a time. Returns false when
+// there are no more.
+func (s *mappedStack[E]) Query(o math32.VolumeType[E]) (uint64, bool) {
+	nodes := s.mapped.nodes
+	point := o.GetPoint()
+	max := point.Add(o.GetDelta())
+
+	for int(s.next) < len(nodes) {
+		node := &nodes[s.next]
+		if !overlapsBounds(node.min, node.max, point, max) {
+			s.next = node.skip
+		} else if node.leaf >= 0 {
+			s.next = node.skip
+			return s.mapped.ids[node.leaf], true
+		} else {
+			s.next++
+		}
+	}
+	return 0, false
+}
+
+// Intersects traces the path of a moving orth through the MappedBVH, returning the ID of a leaf that it hits and the
+// distance from the orth's origin along its delta. Returns false when there are no more. It does not guarantee order.
+func (s *mappedStack[E]) Intersects(orth math32.VolumeType[E], delta *math32.Coordinate[E]) (uint64, E, bool) {
+	nodes := s.mapped.nodes
+	point, size := orth.GetPoint(), orth.GetDelta()
+	one, div := math32.Unit[E](), math32.DivFunc[E]()
+
+	for int(s.next) < len(nodes) {
+		node := &nodes[s.next]
+		if t := intersectsBounds(node.min, node.max, point, size, delta, div); t < 0 || t > one {
+			s.next = node.skip
+		} else if node.leaf >= 0 {
+			s.next = node.skip
+			return s.mapped.ids[node.leaf], t, true
+		} else {
+			s.next++
+		}
+	}
+	return 0, -1, false
+}
+
+// Nearest returns the ID of the leaf closest to point and the squared distance to its bounds, or -1 when the
+// MappedBVH is empty. It does not change the position of the iterator for Query or Intersects.
+func (s *mappedStack[E]) Nearest(point math32.Coordinate[E]) (uint64, E) {
+	var best uint64
+	var bestDist E = -1
+	nodes := s.mapped.nodes
+	if len(nodes) == 0 {
+		return best, bestDist
+	}
+
+	mul := math32.MulFunc[E]()
+	s.stack = append(s.stack[:0], 0)
+	for len(s.stack) > 0 {
+		index := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		node := &nodes[index]
+		dist := distanceSq(point, node.min, node.max, mul)
+		if bestDist >= 0 && dist >= bestDist {
+			continue
+		}
+		if node.leaf >= 0 {
+			best, bestDist = s.mapped.ids[node.leaf], dist
+			continue
+		}
+
+		// Visit the closer child first by pushing it last.
+		first, second := index+1, node.second
+		if distanceSq(point, nodes[second].min, nodes[second].max, mul) >
+			distanceSq(point, nodes[first].min, nodes[first].max, mul) {
+			first, second = second, first
+		}
+		s.stack = append(s.stack, first, second)
+	}
+	return best, bestDist
+}
@@ -0,0 +1,200 @@
+package collision
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// writeMapped writes the FlatBVH to a temporary file, storing the index of each volume in orths as its ID.
+func writeMapped[T VolumeType[E], E Number](t *testing.T, flat *FlatBVH[T, E], orths []T) string {
+	t.Helper()
+	ids := map[any]uint64{}
+	for index, orth := range orths {
+		ids[orth] = uint64(index)
+	}
+	var buf bytes.Buffer
+	if err := flat.Write(&buf, func(orth T) uint64 { return ids[orth] }); err != nil {
+		t.Fatalf("Unable to write: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bvh")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("Unable to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestMappedQuery(t *testing.T) {
+	orths := randomOrths(2000)
+	flat := TopDownBVH[*Orthotope[int32], int32](orths).Freeze()
+	mapped, err := OpenMapped[int32](writeMapped(t, flat, orths), true)
+	if err != nil {
+		t.Fatalf("Unable to open: %v", err)
+	}
+	defer mapped.Close()
+	if mapped.Len() != len(orths) || mapped.GetDepth() != flat.GetDepth() {
+		t.Errorf("Opened %d volumes with depth %d, expected %d with depth %d", mapped.Len(), mapped.GetDepth(),
+			len(orths), flat.GetDepth())
+	}
+
+	flatIter, iter := flat.Iterator(), mapped.Iterator()
+	r := rand.New(rand.NewSource(3))
+	for _, q := range randomOrths(100) {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+		expected := map[uint64]bool{}
+		flatIter.Reset()
+		for found := flatIter.Query(q); found != nil; found = flatIter.Query(q) {
+			expected[uint64(indexOf(orths, found))] = true
+		}
+		iter.Reset()
+		for id, ok := iter.Query(q); ok; id, ok = iter.Query(q) {
+			if !expected[id] {
+				t.Errorf("Querying %v returned unexpected volume %d", q.String(), id)
+			}
+			delete(expected, id)
+		}
+		if len(expected) > 0 {
+			t.Errorf("Querying %v did not return %v", q.String(), expected)
+		}
+
+		// Trace each query a short distance.
+		delta := Coordinate[int32]{r.Int31n(200) - 100, r.Int31n(200) - 100, r.Int31n(200) - 100}
+		traced := map[uint64]int32{}
+		flatIter.Reset()
+		for found, d := flatIter.Intersects(q, &delta); found != nil; found, d = flatIter.Intersects(q, &delta) {
+			traced[uint64(indexOf(orths, found))] = d
+		}
+		iter.Reset()
+		for id, d, ok := iter.Intersects(q, &delta); ok; id, d, ok = iter.Intersects(q, &delta) {
+			if e, found := traced[id]; !found || e != d {
+				t.Errorf("Tracing %v returned unexpected volume %d at %d", q.String(), id, d)
+			}
+			delete(traced, id)
+		}
+		if len(traced) > 0 {
+			t.Errorf("Tracing %v did not return %v", q.String(), traced)
+		}
+
+		point := Coordinate[int32](q.Point)
+		nearest, d := flatIter.Nearest(point)
+		if id, mappedD := iter.Nearest(point); mappedD != d || volDistanceSq(point, orths[id], nil) != d {
+			t.Errorf("Nearest to %v returned %d at %d, expected %v at %d", point, id, mappedD, nearest.String(), d)
+		}
+	}
+}
+
+func TestMappedIntersects(t *testing.T) {
+	tree := getIdealTree()
+	flat := tree.Freeze()
+	mapped, err := OpenMapped[float32](writeMapped(t, flat, flat.vols), true)
+	if err != nil {
+		t.Fatalf("Unable to open: %v", err)
+	}
+	defer mapped.Close()
+
+	query, delta := sweepCases()
+	iter := mapped.Iterator()
+	for in, q := range query {
+		expected := map[uint64]float32{}
+		treeIter := tree.Iterator()
+		for r, d := treeIter.Intersects(q, delta[in]); r != nil; r, d = treeIter.Intersects(q, delta[in]) {
+			expected[uint64(indexOf(flat.vols, r))] = d
+		}
+		iter.Reset()
+		for id, d, ok := iter.Intersects(q, delta[in]); ok; id, d, ok = iter.Intersects(q, delta[in]) {
+			if e, found := expected[id]; !found || e != d {
+				t.Errorf("Tracing %v along %v returned unexpected volume %d at %v", q.String(), delta[in], id, d)
+			}
+			delete(expected, id)
+		}
+		if len(expected) > 0 {
+			t.Errorf("Tracing %v along %v did not return %v", q.String(), delta[in], expected)
+		}
+	}
+}
+
+// indexOf returns the position of orth in orths, or -1.
+func indexOf[T comparable](orths []T, orth T) int {
+	for i, o := range orths {
+		if o == orth {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestMappedFormat(t *testing.T) {
+	orths := make([]*Orthotope[float64], 300)
+	r := rand.New(rand.NewSource(4))
+	for i := range orths {
+		orths[i] = &Orthotope[float64]{
+			Point: Coordinate[float64]{r.Float64() * 100, r.Float64() * 100, r.Float64() * 100},
+			Delta: Coordinate[float64]{r.Float64(), r.Float64(), r.Float64()},
+		}
+	}
+	path := writeMapped(t, LinearBVH[*Orthotope[float64], float64](orths).Freeze(), orths)
+	data, _ := os.ReadFile(path)
+
+	// Decoding gives the same nodes as using them in place, as on big endian platforms.
+	mapped, err := readMapped[float64](data, true)
+	if err != nil {
+		t.Fatalf("Unable to read: %v", err)
+	}
+	decoded := &MappedBVH[float64]{}
+	decoded.decode(data[mappedHeader:], len(mapped.nodes), len(mapped.ids))
+	for i := range mapped.nodes {
+		if decoded.nodes[i] != mapped.nodes[i] {
+			t.Errorf("Decoded node %d as %v, expected %v", i, decoded.nodes[i], mapped.nodes[i])
+		}
+	}
+	for i := range mapped.ids {
+		if decoded.ids[i] != mapped.ids[i] {
+			t.Errorf("Decoded ID %d as %d, expected %d", i, decoded.ids[i], mapped.ids[i])
+		}
+	}
+
+	if _, err := OpenMapped[float32](path, true); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for another numeric type, got %v", err)
+	}
+	if _, err := OpenMapped[int64](path, true); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for another numeric type, got %v", err)
+	}
+	if _, err := readMapped[float64](data[:len(data)-8], true); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for a truncated file, got %v", err)
+	}
+	if _, err := readMapped[float64](data[:10], true); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for a truncated header, got %v", err)
+	}
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)/2]++
+	if _, err := readMapped[float64](corrupt, true); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Expected ErrChecksum, got %v", err)
+	}
+	if _, err := readMapped[float64](corrupt, false); err != nil {
+		t.Errorf("Expected the checksum to be skipped, got %v", err)
+	}
+	if _, err := OpenMapped[float64](filepath.Join(t.TempDir(), "missing"), true); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+
+	// Empty trees may be written and opened.
+	path = writeMapped(t, (&BVol[*Orthotope[float64], float64]{}).Freeze(), nil)
+	empty, err := OpenMapped[float64](path, true)
+	if err != nil || empty.Len() != 0 {
+		t.Fatalf("Unable to open an empty tree: %v", err)
+	}
+	if _, ok := empty.Iterator().Query(orths[0]); ok {
+		t.Errorf("Querying an empty tree returned a volume")
+	}
+	if _, d := empty.Iterator().Nearest(Coordinate[float64]{}); d != -1 {
+		t.Errorf("Nearest for an empty tree returned distance %v", d)
+	}
+	if err := empty.Close(); err != nil {
+		t.Errorf("Unable to close: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package collision
+
+import "github.com/briannoyama/bvh/math32"
+
+// Merkle maintains a content hash for every volume of a BVH: the sum of the hashes of the leaves below it. Since the
+// sum does not depend on how the leaves are grouped, it is kept through rotations like any Aggregate, and subtrees of
+// two BVHs with the same leaves have the same hash even when the rest of the trees differ.
+type Merkle[T math32.VolumeType[E], E math32.Number] struct {
+	agg *Aggregate[T, E, uint64]
+}
+
+// NewMerkle hashes every volume of the BVH with leaf, such as BoundsHash, and keeps the hashes up to date afterwards.
+// The hashes are kept apart from any Aggregate from Augment, but hashing the BVH again replaces the previous Merkle.
+func NewMerkle[T math32.VolumeType[E], E math32.Number](b *BVol[T, E], leaf func(T) uint64) *Merkle[T, E] {
+	return &Merkle[T, E]{agg: augmentSlot(b, Aggregator[T, uint64]{
+		Combine: func(first, second uint64) uint64 {
+			return first + second
+		},
+		Leaf: leaf,
+	}, augMerkle)}
+}
+
+// Merkle hashes the volumes of the BVH (see NewMerkle).
+func (l *LoggedBVol[T, E]) Merkle(leaf func(T) uint64) *Merkle[T, E] {
+	return NewMerkle(&l.bvh, leaf)
+}
+
+// Merkle hashes the volumes of the replica (see NewMerkle). The hashes are kept by Apply and Restore.
+func (r *Replica[T, E]) Merkle(leaf func(T) uint64) *Merkle[T, E] {
+	return NewMerkle(&r.bvh, leaf)
+}
+
+// Hash returns the hash of every volume in the BVH, or 0 when it is empty.
+func (m *Merkle[T, E]) Hash() uint64 {
+	return m.agg.Value()
+}
+
+// BoundsHash hashes the bounds (see GetPoint and GetDelta) of vol for NewMerkle. It does not depend on the platform,
+// so the hashes of replicas may be compared.
+func BoundsHash[T math32.VolumeType[E], E math32.Number](vol T) uint64 {
+	var buf [4 * math32.DIMENSIONS * 10]byte
+	kind := math32.KindOf[E]()
+	data := appendCoordinate(buf[:0], kind, vol.GetPoint())
+	data = appendCoordinate(data, kind, vol.GetDelta())
+
+	// FNV-1a, followed by the finalizer of SplitMix64 so that sums of hashes do not cancel out.
+	hash := uint64(14695981039346656037)
+	for _, b := range data {
+		hash = (hash ^ uint64(b)) * 1099511628211
+	}
+	hash = (hash ^ hash>>30) * 0xbf58476d1ce4e5b9
+	hash = (hash ^ hash>>27) * 0x94d049bb133111eb
+	return hash ^ hash>>31
+}
+
+// Difference lists the leaves that differ between two BVHs (see Diff).
+type Difference[T any] struct {
+	// Added are the leaves only in the second BVH, and Removed those only in the first.
+	Added, Removed []T
+	// Changed pairs a leaf of the first BVH with the leaf of the second that has the same key but other bounds.
+	Changed [][2]T
+}
+
+// Diff finds the leaves that differ between a and b. It descends only into subtrees whose hashes are not matched by a
+// subtree of the other BVH, deepest first, so when the BVHs have the same structure it visits O(d log(n)) volumes for
+// d differences. Leaves that differ and have the same key are reported as Changed; key may be nil. Both BVHs must be
+// hashed with the same leaf function.
+func Diff[T math32.VolumeType[E], E math32.Number](a, b *Merkle[T, E], key func(T) uint64) Difference[T] {
+	first, second := []*BVol[T, E]{a.agg.bvh}, []*BVol[T, E]{b.agg.bvh}
+	for {
+		first, second = unmatched(first, second, a.agg, b.agg)
+		depth := int32(0)
+		for _, bvol := range first {
+			depth = max(depth, bvol.depth)
+		}
+		for _, bvol := range second {
+			depth = max(depth, bvol.depth)
+		}
+		if depth == 0 {
+			break
+		}
+		first, second = expand(first, depth), expand(second, depth)
+	}
+
+	var diff Difference[T]
+	for _, bvol := range first {
+		diff.Removed = append(diff.Removed, bvol.vol)
+	}
+	for _, bvol := range second {
+		diff.Added = append(diff.Added, bvol.vol)
+	}
+	if key == nil {
+		return diff
+	}
+
+	removed := make(map[uint64]int, len(diff.Removed))
+	for index, vol := range diff.Removed {
+		removed[key(vol)] = index
+	}
+	added := diff.Added[:0]
+	matched := make([]bool, len(diff.Removed))
+	for _, vol := range diff.Added {
+		if index, ok := removed[key(vol)]; ok && !matched[index] {
+			diff.Changed = append(diff.Changed, [2]T{diff.Removed[index], vol})
+			matched[index] = true
+		} else {
+			added = append(added, vol)
+		}
+	}
+	diff.Added = added
+	kept := diff.Removed[:0]
+	for index, vol := range diff.Removed {
+		if !matched[index] {
+			kept = append(kept, vol)
+		}
+	}
+	diff.Removed = kept
+	return diff
+}
+
+// unmatched removes empty volumes, and pairs of volumes from first and second with the same hash. The hashes of first
+// are kept by a, and those of second by b.
+func unmatched[T math32.VolumeType[E], E math32.Number](first, second []*BVol[T, E],
+	a, b *Aggregate[T, E, uint64]) ([]*BVol[T, E], []*BVol[T, E]) {
+	hashes := make(map[uint64][]int, len(second))
+	for index, bvol := range second {
+		if !bvol.vol.IsNil() {
+			hash := b.at(bvol)
+			hashes[hash] = append(hashes[hash], index)
+		}
+	}
+	matched := make([]bool, len(second))
+	keep := first[:0]
+	for _, bvol := range first {
+		if bvol.vol.IsNil() {
+			continue
+		}
+		hash := a.at(bvol)
+		if indices := hashes[hash]; len(indices) > 0 {
+			matched[indices[len(indices)-1]] = true
+			hashes[hash] = indices[:len(indices)-1]
+			continue
+		}
+		keep = append(keep, bvol)
+	}
+
+	others := second[:0]
+	for index, bvol := range second {
+		if !matched[index] && !bvol.vol.IsNil() {
+			others = append(others, bvol)
+		}
+	}
+	return keep, others
+}
+
+// expand replaces the volumes of the given depth by their children.
+func expand[T math32.VolumeType[E], E math32.Number](volumes []*BVol[T, E], depth int32) []*BVol[T, E] {
+	expanded := make([]*BVol[T, E], 0, len(volumes)+len(volumes)/2)
+	for _, bvol := range volumes {
+		if bvol.depth == depth {
+			expanded = append(expanded, bvol.desc[0], bvol.desc[1])
+		} else {
+			expanded = append(expanded, bvol)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,194 @@
+package collision
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkHashes verifies the hash of every volume against its leaves.
+func checkHashes(t *testing.T, m *Merkle[*Orthotope[int32], int32], tree *BVol[*Orthotope[int32], int32]) uint64 {
+	t.Helper()
+	var hash uint64
+	if tree.depth > 0 {
+		hash = checkHashes(t, m, tree.desc[0]) + checkHashes(t, m, tree.desc[1])
+	} else if !tree.vol.IsNil() {
+		hash = BoundsHash(tree.vol)
+	}
+	if stored := m.agg.at(tree); stored != hash {
+		t.Errorf("Volume %v has hash %x, expected %x", tree.vol, stored, hash)
+	}
+	return hash
+}
+
+// sameVolumes returns true iff both lists have the same volumes, in any order.
+func sameVolumes(first, second []*Orthotope[int32]) bool {
+	counts := map[*Orthotope[int32]]int{}
+	for _, orth := range first {
+		counts[orth]++
+	}
+	for _, orth := range second {
+		counts[orth]--
+	}
+	for _, count := range counts {
+		if count != 0 {
+			return false
+		}
+	}
+	return len(first) == len(second)
+}
+
+func TestDiff(t *testing.T) {
+	orths := randomOrths(1200)
+	keys := map[*Orthotope[int32]]uint64{}
+	for index, orth := range orths {
+		keys[orth] = uint64(index)
+	}
+	key := func(orth *Orthotope[int32]) uint64 {
+		return keys[orth]
+	}
+
+	a, b := &BVol[*Orthotope[int32], int32]{}, &BVol[*Orthotope[int32], int32]{}
+	first, second := NewMerkle(a, BoundsHash), NewMerkle(b, BoundsHash)
+	if first.Hash() != 0 {
+		t.Errorf("Expected 0 for an empty tree, got %x", first.Hash())
+	}
+	for _, orth := range orths[:1000] {
+		a.Add(orth)
+		b.Add(orth)
+	}
+	if first.Hash() != second.Hash() {
+		t.Errorf("Trees with the same volumes have different hashes")
+	}
+
+	// Remove, add and replace volumes of the second tree, some of them with rotations in a transaction.
+	var changed [][2]*Orthotope[int32]
+	for _, orth := range orths[:5] {
+		b.Remove(orth)
+	}
+	for _, orth := range orths[1000:1005] {
+		b.Add(orth)
+	}
+	txn := b.Begin()
+	for _, orth := range orths[500:510] {
+		updated := moved(orth, 3)
+		keys[updated] = keys[orth]
+		txn.Update(orth, updated)
+		changed = append(changed, [2]*Orthotope[int32]{orth, updated})
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Unable to commit: %v", err)
+	}
+	checkHashes(t, first, a)
+	checkHashes(t, second, b)
+
+	diff := Diff(first, second, key)
+	if !sameVolumes(diff.Removed, orths[:5]) || !sameVolumes(diff.Added, orths[1000:1005]) {
+		t.Errorf("Unexpected difference: removed %v, added %v", diff.Removed, diff.Added)
+	}
+	if len(diff.Changed) != len(changed) {
+		t.Errorf("Expected %d changes, got %v", len(changed), diff.Changed)
+	}
+	for _, pair := range diff.Changed {
+		if key(pair[0]) != key(pair[1]) || !pair[1].Equals(moved(pair[0], 3)) {
+			t.Errorf("Unexpected change from %v to %v", pair[0].String(), pair[1].String())
+		}
+	}
+	if diff := Diff(second, first, nil); len(diff.Added) != 15 || len(diff.Removed) != 15 || diff.Changed != nil {
+		t.Errorf("Expected 15 volumes added and removed without a key, got %v", diff)
+	}
+
+	// Trees with another structure are compared by their contents.
+	c := &BVol[*Orthotope[int32], int32]{}
+	for index := 999; index >= 0; index-- {
+		c.Add(orths[index])
+	}
+	third := NewMerkle(c, BoundsHash)
+	if third.Hash() != first.Hash() {
+		t.Errorf("Trees with the same volumes have different hashes")
+	}
+	if diff := Diff(first, third, key); len(diff.Added)+len(diff.Removed)+len(diff.Changed) > 0 {
+		t.Errorf("Expected no difference, got %v", diff)
+	}
+	c.Remove(orths[999])
+	if diff := Diff(first, third, key); len(diff.Added)+len(diff.Changed) > 0 || !sameVolumes(diff.Removed,
+		orths[999:1000]) {
+		t.Errorf("Expected %v to be removed, got %v", orths[999].String(), diff)
+	}
+
+	empty := NewMerkle(&BVol[*Orthotope[int32], int32]{}, BoundsHash)
+	if diff := Diff(empty, first, nil); len(diff.Added) != 1000 || len(diff.Removed) != 0 {
+		t.Errorf("Expected every volume to be added, got %d", len(diff.Added))
+	}
+}
+
+func TestMerkleAugment(t *testing.T) {
+	orths := randomOrths(600)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	hashes := NewMerkle(tree, BoundsHash)
+	agg := Augment(tree, massAggregator)
+	for _, orth := range orths {
+		tree.Add(orth)
+	}
+	for _, orth := range orths[:100] {
+		tree.Remove(orth)
+	}
+	if hash := checkHashes(t, hashes, tree); hashes.Hash() != hash {
+		t.Errorf("Hash %x, expected %x", hashes.Hash(), hash)
+	}
+	checkAggregate(t, agg, tree)
+	if agg.Value()[0] != 500 {
+		t.Errorf("Expected to count 500 volumes, got %d", agg.Value()[0])
+	}
+
+	// Augmenting again replaces the aggregate, but not the hashes.
+	count := Augment(tree, Aggregator[*Orthotope[int32], int]{
+		Combine: func(first, second int) int { return first + second },
+		Leaf:    func(*Orthotope[int32]) int { return 1 },
+	})
+	for _, orth := range orths[100:200] {
+		tree.Remove(orth)
+	}
+	if agg.Value() != [2]int64{} || count.Value() != 400 {
+		t.Errorf("Expected the replaced aggregate to be empty and a count of 400, got %v and %d", agg.Value(),
+			count.Value())
+	}
+	hash := checkHashes(t, hashes, tree)
+	if rehashed := NewMerkle(tree, BoundsHash); rehashed.Hash() != hash || count.Value() != 400 {
+		t.Errorf("Rehashing changed the hash from %x to %x and the count to %d", hash, rehashed.Hash(),
+			count.Value())
+	}
+}
+
+func TestReplicaHash(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	server := NewLoggedBVol[*Orthotope[int32], int32](OrthotopeCodec[int32]{}, 500)
+	serverHash := server.Merkle(BoundsHash)
+	randomChanges(server, r, 1200)
+
+	replica := NewReplica[*Orthotope[int32], int32](OrthotopeCodec[int32]{})
+	replicaHash := replica.Merkle(BoundsHash)
+	if err := replica.Restore(server.Checkpoint()); err != nil {
+		t.Fatalf("Unable to restore: %v", err)
+	}
+	changes, _ := server.Changes(replica.Seq())
+	if err := replica.Apply(changes); err != nil {
+		t.Fatalf("Unable to apply changes: %v", err)
+	}
+	if replicaHash.Hash() != serverHash.Hash() {
+		t.Errorf("The replica has hash %x, expected %x", replicaHash.Hash(), serverHash.Hash())
+	}
+	checkHashes(t, replicaHash, &replica.bvh)
+
+	randomChanges(server, r, 20)
+	diff := Diff(replicaHash, serverHash, nil)
+	if len(diff.Added)+len(diff.Removed) == 0 {
+		t.Errorf("Expected a difference after changing the server")
+	}
+	changes, _ = server.Changes(replica.Seq())
+	replica.Apply(changes)
+	if diff := Diff(replicaHash, serverHash, nil); len(diff.Added)+len(diff.Removed) > 0 {
+		t.Errorf("Expected no difference after applying the changes, got %v", diff)
+	}
+}
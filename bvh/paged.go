@@ -0,0 +1,696 @@
+package collision
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// PageID identifies a page of a Storage.
+type PageID uint64
+
+// Storage holds the fixed size pages of a PagedBVH, such as in a file or a key value store. MemoryStorage keeps them
+// in memory.
+type Storage interface {
+	// PageSize returns the size of every page in bytes.
+	PageSize() int
+	// ReadPage copies the page into page, which has PageSize bytes.
+	ReadPage(id PageID, page []byte) error
+	// WritePage stores a copy of page, which has PageSize bytes.
+	WritePage(id PageID, page []byte) error
+	// Allocate reserves a page that is not in use. The first page allocated from an empty Storage must be 0.
+	Allocate() (PageID, error)
+	// Free releases a page, which may be returned by Allocate again.
+	Free(id PageID) error
+}
+
+// MemoryStorage is a Storage that keeps pages in memory, for tests and for trees that fit into memory.
+type MemoryStorage struct {
+	pageSize      int
+	pages         [][]byte
+	free          []PageID
+	reads, writes int
+}
+
+// NewMemoryStorage creates an empty MemoryStorage with pages of pageSize bytes.
+func NewMemoryStorage(pageSize int) *MemoryStorage {
+	return &MemoryStorage{pageSize: pageSize}
+}
+
+// PageSize returns the size of every page in bytes.
+func (m *MemoryStorage) PageSize() int {
+	return m.pageSize
+}
+
+// ReadPage copies the page into page. Returns ErrNoPage if the page is not allocated.
+func (m *MemoryStorage) ReadPage(id PageID, page []byte) error {
+	if id >= PageID(len(m.pages)) || m.pages[id] == nil {
+		return fmt.Errorf("%w: %d", ErrNoPage, id)
+	}
+	m.reads++
+	copy(page, m.pages[id])
+	return nil
+}
+
+// WritePage stores a copy of page. Returns ErrNoPage if the page is not allocated.
+func (m *MemoryStorage) WritePage(id PageID, page []byte) error {
+	if id >= PageID(len(m.pages)) || m.pages[id] == nil {
+		return fmt.Errorf("%w: %d", ErrNoPage, id)
+	}
+	m.writes++
+	copy(m.pages[id], page)
+	return nil
+}
+
+// Allocate reserves a page, reusing freed pages first.
+func (m *MemoryStorage) Allocate() (PageID, error) {
+	if len(m.free) > 0 {
+		id := m.free[len(m.free)-1]
+		m.free = m.free[:len(m.free)-1]
+		m.pages[id] = make([]byte, m.pageSize)
+		return id, nil
+	}
+	m.pages = append(m.pages, make([]byte, m.pageSize))
+	return PageID(len(m.pages) - 1), nil
+}
+
+// Free releases a page. Returns ErrNoPage if the page is not allocated.
+func (m *MemoryStorage) Free(id PageID) error {
+	if id >= PageID(len(m.pages)) || m.pages[id] == nil {
+		return fmt.Errorf("%w: %d", ErrNoPage, id)
+	}
+	m.pages[id] = nil
+	m.free = append(m.free, id)
+	return nil
+}
+
+// Len returns the number of pages allocated.
+func (m *MemoryStorage) Len() int {
+	return len(m.pages) - len(m.free)
+}
+
+// Page 0 of the Storage of a PagedBVH holds its header:
+//
+//	magic      [8]byte  "BVHPAGE\x00"
+//	version    uint32
+//	dimensions uint32   math32.DIMENSIONS
+//	kind       uint32   the numeric type (see numericKind)
+//	pageSize   uint32
+//	root       uint64   PageID of the root node
+//	height     uint32   level of the root node, followed by 4 bytes of padding
+//	count      uint64
+//
+// Every other page holds a node: its level (0 for leaves) and number of entries as uint32, followed by the entries.
+// Each entry is the minimum and maximum corners of its bounds, in the layout of appendFixed, and a uint64 that is the
+// PageID of a child for internal nodes or the ID of a volume for leaves. Values are little endian.
+const (
+	pagedMagic   = "BVHPAGE\x00"
+	pagedVersion = 1
+	pagedHeader  = 48
+	nodeHeader   = 8
+)
+
+// pagedEntry is a child of a node of a PagedBVH.
+type pagedEntry[E math32.Number] struct {
+	min, max math32.Coordinate[E]
+	ref      uint64 // PageID of the child, or ID of the volume in leaves.
+}
+
+// bin returns the bounds of the entry, to merge with others.
+func (e *pagedEntry[E]) bin() sahBin[E] {
+	return sahBin[E]{min: e.min, max: e.max, count: 1}
+}
+
+// pagedNode is a decoded page.
+type pagedNode[E math32.Number] struct {
+	level   uint32
+	entries []pagedEntry[E]
+}
+
+// bounds returns the bounds of every entry of the node.
+func (n *pagedNode[E]) bounds() sahBin[E] {
+	var bounds sahBin[E]
+	for index := range n.entries {
+		bin := n.entries[index].bin()
+		bounds.merge(&bin)
+	}
+	return bounds
+}
+
+// pageFrame holds a node in the buffer pool.
+type pageFrame[E math32.Number] struct {
+	id    PageID
+	node  pagedNode[E]
+	dirty bool
+	elem  *list.Element
+}
+
+// pathStep is a node visited on the way to a leaf, and the entry that was followed.
+type pathStep[E math32.Number] struct {
+	frame *pageFrame[E]
+	slot  int
+}
+
+// PagedBVH is a BVH for more volumes than fit into memory. Like an R-tree, each node is a page of a Storage with as
+// many children as fit, and all leaves are at the same level. A buffer pool keeps the most recently used pages
+// decoded, writing back changed pages when they are evicted or on Flush. Leaves are only stored by their bounds (see
+// GetPoint and GetDelta) and ID, like MappedBVH.
+//
+// A PagedBVH is not thread-safe. When the Storage returns an error, the error is returned and the change that was
+// being made may be partially applied.
+type PagedBVH[E math32.Number] struct {
+	storage  Storage
+	frames   map[PageID]*pageFrame[E]
+	lru      *list.List // Frames from the most to the least recently used.
+	capacity int
+	fanout   int
+	minFill  int
+	root     PageID
+	height   uint32
+	count    uint64
+	buf      []byte
+}
+
+// NewPaged creates a PagedBVH in an empty Storage, keeping up to pages pages in its buffer pool. Returns ErrFormat if
+// the Storage is not empty or its pages are too small to hold four entries.
+func NewPaged[E math32.Number](storage Storage, pages int) (*PagedBVH[E], error) {
+	p, err := newPaged[E](storage, pages)
+	if err != nil {
+		return nil, err
+	}
+	header, err := storage.Allocate()
+	if err != nil {
+		return nil, err
+	}
+	if header != 0 {
+		storage.Free(header)
+		return nil, fmt.Errorf("%w: storage is not empty", ErrFormat)
+	}
+	root, err := p.newFrame(0)
+	if err != nil {
+		return nil, err
+	}
+	p.root = root.id
+	return p, p.Flush()
+}
+
+// OpenPaged opens a PagedBVH that was created with NewPaged and flushed, keeping up to pages pages in its buffer
+// pool. Returns ErrFormat if the Storage does not hold a PagedBVH with the same dimensions, numeric type and page
+// size.
+func OpenPaged[E math32.Number](storage Storage, pages int) (*PagedBVH[E], error) {
+	p, err := newPaged[E](storage, pages)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.ReadPage(0, p.buf); err != nil {
+		return nil, err
+	}
+	data := p.buf
+	if string(data[:8]) != pagedMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrFormat)
+	}
+	version := binary.LittleEndian.Uint32(data[8:])
+	dimensions, kind := binary.LittleEndian.Uint32(data[12:]), binary.LittleEndian.Uint32(data[16:])
+	if version != pagedVersion || dimensions != uint32(math32.DIMENSIONS) || kind != numericKind[E]() ||
+		binary.LittleEndian.Uint32(data[20:]) != uint32(len(data)) {
+		return nil, fmt.Errorf("%w: version %d, %d dimensions of kind %d", ErrFormat, version, dimensions, kind)
+	}
+	p.root = PageID(binary.LittleEndian.Uint64(data[24:]))
+	p.height = binary.LittleEndian.Uint32(data[32:])
+	p.count = binary.LittleEndian.Uint64(data[40:])
+	return p, nil
+}
+
+// newPaged creates a PagedBVH without a root.
+func newPaged[E math32.Number](storage Storage, pages int) (*PagedBVH[E], error) {
+	size := storage.PageSize()
+	fanout := (size - nodeHeader) / (2*math32.DIMENSIONS*int(unsafe.Sizeof(E(0))) + 8)
+	if size < pagedHeader || fanout < 4 {
+		return nil, fmt.Errorf("%w: pages of %d bytes hold %d entries", ErrFormat, size, max(fanout, 0))
+	}
+	return &PagedBVH[E]{
+		storage:  storage,
+		frames:   map[PageID]*pageFrame[E]{},
+		lru:      list.New(),
+		capacity: max(pages, 1),
+		fanout:   fanout,
+		minFill:  fanout * 2 / 5,
+		buf:      make([]byte, size),
+	}, nil
+}
+
+// GetDepth returns the level of the root node, ie. the height of the tree. Every leaf is at this depth.
+func (p *PagedBVH[E]) GetDepth() int32 {
+	return int32(p.height)
+}
+
+// Len returns the number of volumes stored.
+func (p *PagedBVH[E]) Len() int {
+	return int(p.count)
+}
+
+// Insert adds the bounds of vol with the given ID. IDs need not be unique. Returns ErrInvalidVolume for volumes that
+// may not be added to a BVH.
+func (p *PagedBVH[E]) Insert(id uint64, vol math32.VolumeType[E]) error {
+	if vol == nil {
+		return fmt.Errorf("%w: nil", ErrInvalidVolume)
+	}
+	if err := validVolume[math32.VolumeType[E], E](vol); err != nil {
+		return err
+	}
+	if err := p.insert(boundsEntry(id, vol), 0); err != nil {
+		return err
+	}
+	p.count++
+	return p.trim()
+}
+
+// Delete removes an entry with the given ID and the bounds of vol. Returns ErrNotFound if there is none.
+func (p *PagedBVH[E]) Delete(id uint64, vol math32.VolumeType[E]) error {
+	if vol == nil || vol.IsNil() {
+		return ErrNotFound
+	}
+	entry := boundsEntry(id, vol)
+	root, err := p.fetch(p.root)
+	if err != nil {
+		return err
+	}
+	path := []pathStep[E]{}
+	if found, err := p.find(root, &entry, &path); err != nil {
+		return err
+	} else if !found {
+		if err := p.trim(); err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+
+	// Remove the entry, then nodes left with too few entries, keeping their entries to insert again.
+	leaf := path[len(path)-1]
+	leaf.frame.node.entries = append(leaf.frame.node.entries[:leaf.slot], leaf.frame.node.entries[leaf.slot+1:]...)
+	leaf.frame.dirty = true
+	var orphans []pagedNode[E]
+	for index := len(path) - 2; index >= 0; index-- {
+		parent, child := path[index], path[index+1].frame
+		if len(child.node.entries) < p.minFill {
+			orphans = append(orphans, child.node)
+			parent.frame.node.entries = append(parent.frame.node.entries[:parent.slot],
+				parent.frame.node.entries[parent.slot+1:]...)
+			if err := p.free(child); err != nil {
+				return err
+			}
+		} else {
+			bounds := child.node.bounds()
+			parent.frame.node.entries[parent.slot].min = bounds.min
+			parent.frame.node.entries[parent.slot].max = bounds.max
+		}
+		parent.frame.dirty = true
+	}
+	p.count--
+	for _, orphan := range orphans {
+		for _, entry := range orphan.entries {
+			if err := p.insert(entry, orphan.level); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Shorten the tree while the root has a single child.
+	for p.height > 0 {
+		root, err := p.fetch(p.root)
+		if err != nil {
+			return err
+		}
+		if len(root.node.entries) != 1 {
+			break
+		}
+		p.root = PageID(root.node.entries[0].ref)
+		p.height--
+		if err := p.free(root); err != nil {
+			return err
+		}
+	}
+	return p.trim()
+}
+
+// boundsEntry returns a leaf entry for vol.
+func boundsEntry[E math32.Number](id uint64, vol math32.VolumeType[E]) pagedEntry[E] {
+	point := vol.GetPoint()
+	return pagedEntry[E]{min: point, max: point.Add(vol.GetDelta()), ref: id}
+}
+
+// insert adds the entry to a node of the given level, choosing the child whose bounds grow least on the way down,
+// and splits nodes that overflow on the way up.
+func (p *PagedBVH[E]) insert(entry pagedEntry[E], level uint32) error {
+	frame, err := p.fetch(p.root)
+	if err != nil {
+		return err
+	}
+	var path []pathStep[E]
+	for frame.node.level > level {
+		slot := chooseEntry(frame.node.entries, &entry)
+		path = append(path, pathStep[E]{frame, slot})
+		if frame, err = p.fetch(PageID(frame.node.entries[slot].ref)); err != nil {
+			return err
+		}
+	}
+
+	// Allocate the pages for splits before changing any node.
+	splits := 0
+	if len(frame.node.entries) >= p.fanout {
+		splits++
+		for index := len(path) - 1; index >= 0 && len(path[index].frame.node.entries) >= p.fanout; index-- {
+			splits++
+		}
+		if splits > len(path) {
+			// The root splits, so it needs a new parent.
+			splits++
+		}
+	}
+	pages := make([]PageID, 0, splits)
+	for len(pages) < splits {
+		id, err := p.storage.Allocate()
+		if err != nil {
+			for _, page := range pages {
+				p.storage.Free(page)
+			}
+			return err
+		}
+		pages = append(pages, id)
+	}
+
+	frame.node.entries = append(frame.node.entries, entry)
+	frame.dirty = true
+	var sibling *pageFrame[E]
+	if len(frame.node.entries) > p.fanout {
+		sibling, pages = p.split(frame, pages[0]), pages[1:]
+	}
+	for index := len(path) - 1; index >= 0; index-- {
+		parent, slot := path[index].frame, path[index].slot
+		bounds := frame.node.bounds()
+		parent.node.entries[slot].min, parent.node.entries[slot].max = bounds.min, bounds.max
+		parent.dirty = true
+		if sibling != nil {
+			bounds = sibling.node.bounds()
+			parent.node.entries = append(parent.node.entries, pagedEntry[E]{bounds.min, bounds.max,
+				uint64(sibling.id)})
+			sibling = nil
+			if len(parent.node.entries) > p.fanout {
+				sibling, pages = p.split(parent, pages[0]), pages[1:]
+			}
+		}
+		frame = parent
+	}
+	if sibling != nil {
+		root := p.addFrame(pages[0], frame.node.level+1)
+		for _, child := range [2]*pageFrame[E]{frame, sibling} {
+			bounds := child.node.bounds()
+			root.node.entries = append(root.node.entries, pagedEntry[E]{bounds.min, bounds.max, uint64(child.id)})
+		}
+		p.root = root.id
+		p.height++
+	}
+	return nil
+}
+
+// chooseEntry returns the entry whose bounds grow the least to contain entry, preferring smaller entries.
+func chooseEntry[E math32.Number](entries []pagedEntry[E], entry *pagedEntry[E]) int {
+	added := entry.bin()
+	best := 0
+	var bestGrowth, bestScore E
+	for index := range entries {
+		bin := entries[index].bin()
+		score := bin.score()
+		bin.merge(&added)
+		growth := bin.score() - score
+		if index == 0 || growth < bestGrowth || (growth == bestGrowth && score < bestScore) {
+			best, bestGrowth, bestScore = index, growth, score
+		}
+	}
+	return best
+}
+
+// split moves part of the entries of the overflowing frame into a new frame with the given PageID. The entries are
+// sorted by their centers along each dimension, and split where the sum of the scores of both halves weighted by
+// their number of entries is lowest, as in BinnedSAHBVH.
+func (p *PagedBVH[E]) split(frame *pageFrame[E], id PageID) *pageFrame[E] {
+	entries := frame.node.entries
+	n := len(entries)
+	sortBy := func(dim int) {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].min[dim]+(entries[i].max[dim]-entries[i].min[dim])/2 <
+				entries[j].min[dim]+(entries[j].max[dim]-entries[j].min[dim])/2
+		})
+	}
+
+	bestDim, bestSplit, bestCost := 0, 0, 0.0
+	prefix := make([]sahBin[E], n)
+	for dim := 0; dim < math32.DIMENSIONS; dim++ {
+		sortBy(dim)
+		for index := range entries {
+			prefix[index] = entries[index].bin()
+			if index > 0 {
+				prefix[index].merge(&prefix[index-1])
+			}
+		}
+		var suffix sahBin[E]
+		for index := n - 1; index >= p.minFill; index-- {
+			bin := entries[index].bin()
+			suffix.merge(&bin)
+			if n-index < p.minFill {
+				continue
+			}
+			cost := float64(prefix[index-1].score())*float64(index) + float64(suffix.score())*float64(n-index)
+			if bestSplit == 0 || cost < bestCost {
+				bestDim, bestSplit, bestCost = dim, index, cost
+			}
+		}
+	}
+	sortBy(bestDim)
+
+	sibling := p.addFrame(id, frame.node.level)
+	sibling.node.entries = append(sibling.node.entries, entries[bestSplit:]...)
+	frame.node.entries = entries[:bestSplit]
+	frame.dirty = true
+	return sibling
+}
+
+// find appends the path from frame to an entry of a leaf that equals entry, and returns whether there is one. Only
+// children whose bounds contain those of entry are searched.
+func (p *PagedBVH[E]) find(frame *pageFrame[E], entry *pagedEntry[E], path *[]pathStep[E]) (bool, error) {
+	for slot := range frame.node.entries {
+		child := &frame.node.entries[slot]
+		if frame.node.level == 0 {
+			if *child == *entry {
+				*path = append(*path, pathStep[E]{frame, slot})
+				return true, nil
+			}
+			continue
+		}
+		if !overlapsBounds(child.min, child.max, entry.min, entry.min) ||
+			!overlapsBounds(child.min, child.max, entry.max, entry.max) {
+			continue
+		}
+		next, err := p.fetch(PageID(child.ref))
+		if err != nil {
+			return false, err
+		}
+		*path = append(*path, pathStep[E]{frame, slot})
+		if found, err := p.find(next, entry, path); found || err != nil {
+			return found, err
+		}
+		*path = (*path)[:len(*path)-1]
+	}
+	return false, nil
+}
+
+// fetch returns the frame of a page, reading it into the buffer pool if needed. Frames are only evicted by trim, so
+// those fetched during an operation stay valid until it ends.
+func (p *PagedBVH[E]) fetch(id PageID) (*pageFrame[E], error) {
+	if frame, ok := p.frames[id]; ok {
+		p.lru.MoveToFront(frame.elem)
+		return frame, nil
+	}
+	if err := p.storage.ReadPage(id, p.buf); err != nil {
+		return nil, err
+	}
+	data := p.buf
+	level, count := binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint32(data[4:])
+	if level > p.height || count > uint32(p.fanout) {
+		return nil, fmt.Errorf("%w: page %d", ErrCorrupt, id)
+	}
+	frame := p.addFrame(id, level)
+	frame.dirty = false
+	size, kind := int(unsafe.Sizeof(E(0))), math32.KindOf[E]()
+	data = data[nodeHeader:]
+	for index := uint32(0); index < count; index++ {
+		var entry pagedEntry[E]
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			entry.min[d] = readFixed[E](data[d*size:], kind)
+			entry.max[d] = readFixed[E](data[(d+math32.DIMENSIONS)*size:], kind)
+		}
+		data = data[2*math32.DIMENSIONS*size:]
+		entry.ref = binary.LittleEndian.Uint64(data)
+		data = data[8:]
+		frame.node.entries = append(frame.node.entries, entry)
+	}
+	return frame, nil
+}
+
+// addFrame adds an empty node with the given PageID to the buffer pool.
+func (p *PagedBVH[E]) addFrame(id PageID, level uint32) *pageFrame[E] {
+	frame := &pageFrame[E]{id: id, dirty: true}
+	frame.node.level = level
+	frame.node.entries = make([]pagedEntry[E], 0, p.fanout+1)
+	frame.elem = p.lru.PushFront(frame)
+	p.frames[id] = frame
+	return frame
+}
+
+// newFrame allocates a page for an empty node.
+func (p *PagedBVH[E]) newFrame(level uint32) (*pageFrame[E], error) {
+	id, err := p.storage.Allocate()
+	if err != nil {
+		return nil, err
+	}
+	return p.addFrame(id, level), nil
+}
+
+// free removes the frame from the buffer pool and frees its page.
+func (p *PagedBVH[E]) free(frame *pageFrame[E]) error {
+	p.lru.Remove(frame.elem)
+	delete(p.frames, frame.id)
+	return p.storage.Free(frame.id)
+}
+
+// write encodes the node of the frame and writes it to its page.
+func (p *PagedBVH[E]) write(frame *pageFrame[E]) error {
+	clear(p.buf)
+	buf := binary.LittleEndian.AppendUint32(p.buf[:0], frame.node.level)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(frame.node.entries)))
+	kind := math32.KindOf[E]()
+	for _, entry := range frame.node.entries {
+		for _, c := range [2]math32.Coordinate[E]{entry.min, entry.max} {
+			for _, value := range c {
+				buf = appendFixed(buf, kind, value)
+			}
+		}
+		buf = binary.LittleEndian.AppendUint64(buf, entry.ref)
+	}
+	if err := p.storage.WritePage(frame.id, p.buf); err != nil {
+		return err
+	}
+	frame.dirty = false
+	return nil
+}
+
+// trim evicts the least recently used frames until the buffer pool is within its capacity, writing back those that
+// changed.
+func (p *PagedBVH[E]) trim() error {
+	for len(p.frames) > p.capacity {
+		frame := p.lru.Back().Value.(*pageFrame[E])
+		if frame.dirty {
+			if err := p.write(frame); err != nil {
+				return err
+			}
+		}
+		p.lru.Remove(frame.elem)
+		delete(p.frames, frame.id)
+	}
+	return nil
+}
+
+// Flush writes every changed page in the buffer pool, and the header, to the Storage.
+func (p *PagedBVH[E]) Flush() error {
+	for elem := p.lru.Front(); elem != nil; elem = elem.Next() {
+		if frame := elem.Value.(*pageFrame[E]); frame.dirty {
+			if err := p.write(frame); err != nil {
+				return err
+			}
+		}
+	}
+	header := p.buf
+	clear(header)
+	copy(header, pagedMagic)
+	binary.LittleEndian.PutUint32(header[8:], pagedVersion)
+	binary.LittleEndian.PutUint32(header[12:], uint32(math32.DIMENSIONS))
+	binary.LittleEndian.PutUint32(header[16:], numericKind[E]())
+	binary.LittleEndian.PutUint32(header[20:], uint32(len(header)))
+	binary.LittleEndian.PutUint64(header[24:], uint64(p.root))
+	binary.LittleEndian.PutUint32(header[32:], p.height)
+	binary.LittleEndian.PutUint64(header[40:], p.count)
+	if err := p.storage.WritePage(0, p.buf); err != nil {
+		return err
+	}
+	return p.trim()
+}
+
+// Iterator for querying the PagedBVH. Since the iterator reads pages through the buffer pool of the PagedBVH, it may
+// not be used while the PagedBVH changes.
+func (p *PagedBVH[E]) Iterator() *pagedStack[E] {
+	stack := &pagedStack[E]{paged: p}
+	stack.Reset()
+	return stack
+}
+
+// pagedStack provides Query for a PagedBVH, returning the IDs of leaves. The stack holds pages whose bounds passed
+// the test of their parent, and found holds the IDs from the last leaf read.
+type pagedStack[E math32.Number] struct {
+	paged *PagedBVH[E]
+	stack []PageID
+	found []uint64
+	err   error
+}
+
+// Reset the iterator to the root of the PagedBVH.
+func (s *pagedStack[E]) Reset() {
+	s.stack = append(s.stack[:0], s.paged.root)
+	s.found = s.found[:0]
+	s.err = nil
+}
+
+// Query looks for leaves whose bounds overlap those of o, returning the ID of one at a time. Returns false when
+// there are no more, or when a page could not be read (see Err).
+func (s *pagedStack[E]) Query(o math32.VolumeType[E]) (uint64, bool) {
+	point := o.GetPoint()
+	max := point.Add(o.GetDelta())
+
+	for len(s.found) == 0 {
+		if len(s.stack) == 0 || s.err != nil {
+			return 0, false
+		}
+		id := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		frame, err := s.paged.fetch(id)
+		if err != nil {
+			s.err = err
+			return 0, false
+		}
+		for index := len(frame.node.entries) - 1; index >= 0; index-- {
+			entry := &frame.node.entries[index]
+			if !overlapsBounds(entry.min, entry.max, point, max) {
+				continue
+			}
+			if frame.node.level == 0 {
+				s.found = append(s.found, entry.ref)
+			} else {
+				s.stack = append(s.stack, PageID(entry.ref))
+			}
+		}
+		if err := s.paged.trim(); err != nil {
+			s.err = err
+		}
+	}
+	id := s.found[len(s.found)-1]
+	s.found = s.found[:len(s.found)-1]
+	return id, true
+}
+
+// Err returns the error that ended the last query, if any.
+func (s *pagedStack[E]) Err() error {
+	return s.err
+}
@@ -0,0 +1,171 @@
+package collision
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkPaged verifies the levels, fill and bounds of every node below the page, and returns the number of leaves.
+func checkPaged(t *testing.T, p *PagedBVH[int32], id PageID, level uint32) int {
+	t.Helper()
+	frame, err := p.fetch(id)
+	if err != nil {
+		t.Fatalf("Unable to read page %d: %v", id, err)
+	}
+	node := frame.node
+	if node.level != level || (id != p.root && len(node.entries) < p.minFill) || len(node.entries) > p.fanout {
+		t.Errorf("Page %d has %d entries at level %d, expected level %d", id, len(node.entries), node.level, level)
+	}
+	if level == 0 {
+		return len(node.entries)
+	}
+	leaves := 0
+	for _, entry := range node.entries {
+		leaves += checkPaged(t, p, PageID(entry.ref), level-1)
+		child, _ := p.fetch(PageID(entry.ref))
+		if bounds := child.node.bounds(); bounds.min != entry.min || bounds.max != entry.max {
+			t.Errorf("Page %d has bounds %v %v, expected %v %v", entry.ref, entry.min, entry.max, bounds.min,
+				bounds.max)
+		}
+	}
+	return leaves
+}
+
+// checkPagedQueries compares queries of the PagedBVH with those of the volumes that are present.
+func checkPagedQueries(t *testing.T, p *PagedBVH[int32], orths []*Orthotope[int32], present []bool) {
+	t.Helper()
+	if leaves := checkPaged(t, p, p.root, p.height); leaves != p.Len() {
+		t.Errorf("Found %d leaves, expected %d", leaves, p.Len())
+	}
+	p.trim()
+	iter := p.Iterator()
+	for _, q := range randomOrths(50) {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+		expected := map[uint64]bool{}
+		for index, orth := range orths {
+			if present[index] && orth.Overlaps(q) {
+				expected[uint64(index)] = true
+			}
+		}
+		iter.Reset()
+		for id, ok := iter.Query(q); ok; id, ok = iter.Query(q) {
+			if !expected[id] {
+				t.Errorf("Querying %v returned unexpected volume %d", q.String(), id)
+			}
+			delete(expected, id)
+		}
+		if iter.Err() != nil || len(expected) > 0 {
+			t.Errorf("Querying %v did not return %v: %v", q.String(), expected, iter.Err())
+		}
+		if len(p.frames) > p.capacity {
+			t.Errorf("The buffer pool has %d pages, expected at most %d", len(p.frames), p.capacity)
+		}
+	}
+}
+
+func TestPaged(t *testing.T) {
+	storage := NewMemoryStorage(512)
+	p, err := NewPaged[int32](storage, 16)
+	if err != nil {
+		t.Fatalf("Unable to create: %v", err)
+	}
+	if p.fanout != 15 {
+		t.Errorf("Expected 15 entries per page, got %d", p.fanout)
+	}
+	orths := randomOrths(3000)
+	present := make([]bool, len(orths))
+	for index, orth := range orths {
+		if err := p.Insert(uint64(index), orth); err != nil {
+			t.Fatalf("Unable to insert %v: %v", orth.String(), err)
+		}
+		present[index] = true
+	}
+	if p.Len() != len(orths) || p.GetDepth() < 2 {
+		t.Errorf("Expected %d volumes in a deeper tree, got %d with depth %d", len(orths), p.Len(), p.GetDepth())
+	}
+	checkPagedQueries(t, p, orths, present)
+	if storage.reads == 0 || storage.writes == 0 {
+		t.Errorf("Expected pages to be evicted and read again")
+	}
+
+	if err := p.Insert(0, nil); !errors.Is(err, ErrInvalidVolume) {
+		t.Errorf("Expected ErrInvalidVolume, got %v", err)
+	}
+	if err := p.Delete(1, orths[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another ID, got %v", err)
+	}
+	for index := 0; index < len(orths); index += 2 {
+		if err := p.Delete(uint64(index), orths[index]); err != nil {
+			t.Fatalf("Unable to delete %v: %v", orths[index].String(), err)
+		}
+		present[index] = false
+	}
+	if p.Len() != len(orths)/2 {
+		t.Errorf("Expected %d volumes, got %d", len(orths)/2, p.Len())
+	}
+	checkPagedQueries(t, p, orths, present)
+
+	// The tree may be opened again after it is flushed.
+	if err := p.Flush(); err != nil {
+		t.Fatalf("Unable to flush: %v", err)
+	}
+	reopened, err := OpenPaged[int32](storage, 4)
+	if err != nil || reopened.Len() != p.Len() || reopened.GetDepth() != p.GetDepth() {
+		t.Fatalf("Unable to open: %v", err)
+	}
+	checkPagedQueries(t, reopened, orths, present)
+	if _, err := OpenPaged[float32](storage, 4); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for another numeric type, got %v", err)
+	}
+	if _, err := NewPaged[int32](storage, 4); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for a storage that is not empty, got %v", err)
+	}
+
+	for index := 1; index < len(orths); index += 2 {
+		if err := reopened.Delete(uint64(index), orths[index]); err != nil {
+			t.Fatalf("Unable to delete %v: %v", orths[index].String(), err)
+		}
+	}
+	reopened.Flush()
+	if reopened.Len() != 0 || reopened.GetDepth() != 0 || storage.Len() != 2 {
+		t.Errorf("Expected an empty tree in 2 pages, got %d volumes with depth %d in %d pages", reopened.Len(),
+			reopened.GetDepth(), storage.Len())
+	}
+}
+
+// failingStorage returns errors once fail is set.
+type failingStorage struct {
+	*MemoryStorage
+	fail bool
+}
+
+func (f *failingStorage) ReadPage(id PageID, page []byte) error {
+	if f.fail {
+		return ErrNoPage
+	}
+	return f.MemoryStorage.ReadPage(id, page)
+}
+
+func TestPagedErrors(t *testing.T) {
+	storage := &failingStorage{MemoryStorage: NewMemoryStorage(256)}
+	p, _ := NewPaged[int32](storage, 2)
+	orths := randomOrths(200)
+	for index, orth := range orths {
+		p.Insert(uint64(index), orth)
+	}
+	storage.fail = true
+	iter := p.Iterator()
+	for _, ok := iter.Query(orths[0]); ok; _, ok = iter.Query(orths[0]) {
+	}
+	if !errors.Is(iter.Err(), ErrNoPage) {
+		t.Errorf("Expected the error of the storage, got %v", iter.Err())
+	}
+	if err := p.Insert(0, orths[0]); !errors.Is(err, ErrNoPage) || p.Len() != len(orths) {
+		t.Errorf("Expected the error of the storage and no change, got %v", err)
+	}
+	if _, err := NewPaged[float64](NewMemoryStorage(128), 2); !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for small pages, got %v", err)
+	}
+}
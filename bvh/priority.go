@@ -0,0 +1,93 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// SetPriority changes the priority (eg. z-order) of an orth already in the BVH. Volumes are added with priority 0.
+// Returns false if it was not found.
+func (s *orthStack[T, E]) SetPriority(o T, priority int32) bool {
+	s.Reset()
+	bvol := s.path(o)
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
+		return false
+	}
+	s.own()
+	bvol, _ = s.pop()
+	bvol.setPriority(priority)
+	for s.HasNext() {
+		bvol, _ = s.pop()
+		bvol.setPriority(max(bvol.desc[0].priority(), bvol.desc[1].priority()))
+	}
+	return true
+}
+
+// priority returns the priority of a leaf, or the maximum priority of the leaves below the volume.
+func (b *BVol[T, E]) priority() int32 {
+	if b.opt == nil {
+		return 0
+	}
+	return b.opt.priority
+}
+
+// setPriority changes the priority of the volume, which creates its options unless it is 0.
+func (b *BVol[T, E]) setPriority(priority int32) {
+	if b.opt != nil || priority != 0 {
+		b.options().priority = priority
+	}
+}
+
+// Priority returns the priority of an orth in the BVH, or 0 if it was not found.
+func (s *orthStack[T, E]) Priority(o T) int32 {
+	s.Reset()
+	bvol := s.path(o)
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
+		return 0
+	}
+	return bvol.priority()
+}
+
+// TopmostAt returns the volume with the highest priority whose bounds (see GetPoint and GetDelta) contain point, and
+// its priority. Of volumes with the same priority, any may be returned. Branches whose maximum priority is not above
+// the best volume found so far are skipped. The stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) TopmostAt(point math32.Coordinate[E]) (T, int32) {
+	var best T
+	var bestPriority int32
+	s.Reset()
+	if s.bvh.vol.IsNil() {
+		s.pop()
+		return best, bestPriority
+	}
+
+	for s.HasNext() {
+		bvol, _ := s.pop()
+		if (!best.IsNil() && bvol.priority() <= bestPriority) || !containsPoint(bvol.vol, point) {
+			continue
+		}
+		if bvol.depth == 0 {
+			best, bestPriority = bvol.vol, bvol.priority()
+			continue
+		}
+
+		// Visit the child with the higher priority first by pushing it last.
+		first, second := bvol.desc[0], bvol.desc[1]
+		if second.priority() > first.priority() {
+			first, second = second, first
+		}
+		s.append(second, 0)
+		s.append(first, 0)
+	}
+	return best, bestPriority
+}
+
+// containsPoint returns true if the bounds of vol contain point. It only compares coordinates, so unlike a squared
+// distance it cannot overflow.
+func containsPoint[T math32.VolumeType[E], E math32.Number](vol T, point math32.Coordinate[E]) bool {
+	min, delta := vol.GetPoint(), vol.GetDelta()
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		if point[d] < min[d] || point[d] > min[d]+delta[d] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package collision
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestTopmostAt(t *testing.T) {
+	orths := randomOrths(1000)
+	priorities := map[*Orthotope[int32]]int32{}
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := NewArena[*Orthotope[int32], int32](0).Iterator(tree)
+	r := rand.New(rand.NewSource(9))
+	for _, orth := range orths {
+		iter.Add(orth)
+		priorities[orth] = r.Int31n(20) - 5
+		if !iter.SetPriority(orth, priorities[orth]) || iter.Priority(orth) != priorities[orth] {
+			t.Errorf("Unable to set the priority of %v", orth.String())
+		}
+	}
+	for _, orth := range orths[:400] {
+		iter.Remove(orth)
+		delete(priorities, orth)
+	}
+
+	iter.Reset()
+	for iter.HasNext() {
+		next := iter.Next()
+		if next.depth > 0 && next.priority() != max(next.desc[0].priority(), next.desc[1].priority()) {
+			t.Errorf("Volume %v has priority %d", next.vol.String(), next.priority())
+		}
+	}
+
+	// Look at the corners of the remaining volumes, as well as random points.
+	points := make([]Coordinate[int32], 0, 800)
+	for _, orth := range orths[400:] {
+		points = append(points, Coordinate[int32](orth.Point).Add(Coordinate[int32](orth.Delta)))
+	}
+	for _, q := range randomOrths(200) {
+		points = append(points, q.Point)
+	}
+	hits := 0
+	for _, point := range points {
+		var expected *Orthotope[int32]
+		for orth, priority := range priorities {
+			if volDistanceSq(point, orth, nil) == 0 && (expected == nil || priority > priorities[expected]) {
+				expected = orth
+			}
+		}
+		topmost, priority := iter.TopmostAt(point)
+		if topmost != nil {
+			hits++
+		}
+		if expected == nil {
+			if topmost != nil {
+				t.Errorf("Found %v at %v, expected nothing", topmost.String(), point)
+			}
+		} else if topmost == nil || priority != priorities[expected] || priorities[topmost] != priority ||
+			volDistanceSq(point, topmost, nil) != 0 {
+			t.Errorf("Found %v with priority %d at %v, expected %v with %d", topmost, priority, point,
+				expected.String(), priorities[expected])
+		}
+	}
+
+	if hits < 600 {
+		t.Errorf("Expected at least 600 hits, got %d", hits)
+	}
+
+	empty := (&BVol[*Orthotope[int32], int32]{}).Iterator()
+	if topmost, _ := empty.TopmostAt(Coordinate[int32]{}); topmost != nil {
+		t.Errorf("Found %v in an empty hierarchy", topmost.String())
+	}
+}
+
+func TestTopmostAtLarge(t *testing.T) {
+	// The squared distance to far, which is 65536 along each axis, overflows int32.
+	near := &Orthotope[int32]{Point: Coordinate[int32]{-1, -1, -1}, Delta: Coordinate[int32]{2, 2, 2}}
+	far := &Orthotope[int32]{Point: Coordinate[int32]{65536, 65536, 65536}, Delta: Coordinate[int32]{1, 1, 1}}
+	tree := &BVol[*Orthotope[int32], int32]{}
+	iter := tree.Iterator()
+	iter.Add(near)
+	iter.Add(far)
+	iter.SetPriority(near, 1)
+	iter.SetPriority(far, 2)
+	if topmost, priority := iter.TopmostAt(Coordinate[int32]{}); topmost != near || priority != 1 {
+		t.Errorf("Found %v with priority %d, expected %v", topmost, priority, near.String())
+	}
+	if topmost, _ := iter.TopmostAt(Coordinate[int32]{-65535, 0, 0}); topmost != nil {
+		t.Errorf("Found %v, expected nothing", topmost.String())
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package collision
+
+// raceEnabled is true when testing with the race detector, which makes sync.Pool drop items at random.
+const raceEnabled = true
@@ -0,0 +1,133 @@
+package collision
+
+import (
+	"math/bits"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// radiusGroup is the number of neighbouring queries that AllWithinRadius answers with a single traversal.
+const radiusGroup int = 16
+
+// sphereBounds is implemented by volumes bounded by a sphere, such as math32.Sphere.
+type sphereBounds[E math32.Number] interface {
+	GetCenter() math32.Coordinate[E]
+	GetRadius() E
+}
+
+// withinRadius returns true iff vol is within r of center. Spheres are tested exactly, and other volumes by their
+// bounds (see GetPoint and GetDelta), which is exact for points and orthotopes. mul is from math32.MulFunc.
+func withinRadius[T math32.VolumeType[E], E math32.Number](vol T, center math32.Coordinate[E], r E,
+	mul func(a, b E) E) bool {
+	if sphere, ok := any(vol).(sphereBounds[E]); ok {
+		sphereCenter := sphere.GetCenter()
+		return within(center, sphereCenter, sphereCenter, math32.AddSat(r, sphere.GetRadius()), mul)
+	}
+	min := vol.GetPoint()
+	return within(center, min, min.Add(vol.GetDelta()), r, mul)
+}
+
+// within returns true iff the bounds given as minimum and maximum corners are within r of point. The squares of
+// integer (and fixed point) distances overflow E for large radii, so they are summed exactly in 128 bits.
+func within[E math32.Number](point, min, max math32.Coordinate[E], r E, mul func(a, b E) E) bool {
+	if math32.IsFloat[E]() {
+		return distanceSq(point, min, max, mul) <= square(r, mul)
+	} else if r < 0 {
+		return false
+	}
+
+	var hi, lo uint64
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		// The difference of two int64s always fits in a uint64.
+		var diff uint64
+		if point[d] < min[d] {
+			diff = uint64(int64(min[d])) - uint64(int64(point[d]))
+		} else if point[d] > max[d] {
+			diff = uint64(int64(point[d])) - uint64(int64(max[d]))
+		}
+		high, low := bits.Mul64(diff, diff)
+		var carry uint64
+		lo, carry = bits.Add64(lo, low, 0)
+		if hi, carry = bits.Add64(hi, high, carry); carry != 0 {
+			return false
+		}
+	}
+	rHi, rLo := bits.Mul64(uint64(int64(r)), uint64(int64(r)))
+	return hi < rHi || (hi == rHi && lo <= rLo)
+}
+
+// square returns r * r. mul is from math32.MulFunc.
+func square[E math32.Number](r E, mul func(a, b E) E) E {
+	if mul != nil {
+		return mul(r, r)
+	}
+	return r * r
+}
+
+// WithinRadius calls found for each volume within r of center, until found returns false. Branches whose bounds are
+// farther than r are skipped. The stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) WithinRadius(center math32.Coordinate[E], r E, found func(T) bool) {
+	mul := math32.MulFunc[E]()
+	s.Walk(func(bounds T, _ int32) bool {
+		return withinRadius(bounds, center, r, mul)
+	}, found)
+}
+
+// AllWithinRadius calls found with each volume within r of each of the centers, such as for finding the neighbours of
+// every particle in a simulation. The centers are sorted along a Morton curve, and up to radiusGroup neighbouring
+// centers share one traversal. found is called in order of the groups rather than of index. The
+// stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) AllWithinRadius(centers []math32.Coordinate[E], r E, found func(index int, neighbour T)) {
+	if len(centers) == 0 {
+		return
+	}
+	low, high := centers[0], centers[0]
+	for _, center := range centers {
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			low[d], high[d] = min(low[d], center[d]), max(high[d], center[d])
+		}
+	}
+	codes := make([]uint64, len(centers))
+	order := make([]int, len(centers))
+	for index, center := range centers {
+		codes[index] = mortonPoint(center, low, high)
+		order[index] = index
+	}
+	radixSort(codes, order)
+
+	mul := math32.MulFunc[E]()
+	for start := 0; start < len(order); {
+		// Group the following centers while they are no farther apart than the diameter of the query.
+		end := start + 1
+		groupLow, groupHigh := centers[order[start]], centers[order[start]]
+		for ; end < len(order) && end-start < radiusGroup; end++ {
+			nextLow, nextHigh := groupLow, groupHigh
+			near := true
+			for d, value := range centers[order[end]] {
+				nextLow[d], nextHigh[d] = min(nextLow[d], value), max(nextHigh[d], value)
+				near = near && nextHigh[d]-nextLow[d] <= math32.AddSat(r, r)
+			}
+			if !near {
+				break
+			}
+			groupLow, groupHigh = nextLow, nextHigh
+		}
+		group := order[start:end]
+		start = end
+		for d := range groupLow {
+			groupLow[d], groupHigh[d] = math32.AddSat(groupLow[d], -r), math32.AddSat(groupHigh[d], r)
+		}
+
+		s.Walk(func(bounds T, _ int32) bool {
+			point := bounds.GetPoint()
+			return overlapsBounds(point, point.Add(bounds.GetDelta()), groupLow, groupHigh)
+		}, func(leaf T) bool {
+			for _, index := range group {
+				if withinRadius(leaf, centers[index], r, mul) {
+					found(index, leaf)
+				}
+			}
+			return true
+		})
+	}
+}
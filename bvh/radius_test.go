@@ -0,0 +1,173 @@
+package collision
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestWithinRadius(t *testing.T) {
+	// Points and boxes.
+	orths := randomOrths(1000)
+	for _, orth := range orths[:500] {
+		orth.Delta = Coordinate[int32]{}
+	}
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Iterator()
+	for _, q := range randomOrths(100) {
+		expected := map[*Orthotope[int32]]bool{}
+		for _, orth := range orths {
+			if volDistanceSq(q.Point, orth, nil) <= 50*50 {
+				expected[orth] = true
+			}
+		}
+		iter.WithinRadius(q.Point, 50, func(orth *Orthotope[int32]) bool {
+			if !expected[orth] {
+				t.Errorf("Found %v farther than 50 from %v", orth.String(), q.Point)
+			}
+			delete(expected, orth)
+			return true
+		})
+		for orth := range expected {
+			t.Errorf("Did not find %v within 50 of %v", orth.String(), q.Point)
+		}
+	}
+
+	// Spheres are compared by their centers rather than their bounds.
+	r := rand.New(rand.NewSource(11))
+	spheres := make([]*Sphere[float32], 300)
+	tree := &BVol[*Sphere[float32], float32]{}
+	for i := range spheres {
+		spheres[i] = &Sphere[float32]{Radius: float32(r.Intn(10))}
+		for d := range spheres[i].Center {
+			spheres[i].Center[d] = float32(r.Intn(200))
+		}
+		tree.Add(spheres[i])
+	}
+	center := Coordinate[float32]{100, 100, 100}
+	count := 0
+	tree.Iterator().WithinRadius(center, 40, func(sphere *Sphere[float32]) bool {
+		if Distance(center, sphere.Center) > 40+sphere.Radius {
+			t.Errorf("Found %v farther than 40 from %v", sphere.String(), center)
+		}
+		count++
+		return true
+	})
+	expected := 0
+	for _, sphere := range spheres {
+		if Distance(center, sphere.Center) <= 40+sphere.Radius {
+			expected++
+		}
+	}
+	if count != expected {
+		t.Errorf("Found %d spheres within 40 of %v, expected %d", count, center, expected)
+	}
+}
+
+func TestWithinRadiusLarge(t *testing.T) {
+	// A radius of 50000 squared overflows int32, as do the squared distances to these points.
+	near := &Orthotope[int32]{Point: Coordinate[int32]{17, 0, 0}}
+	inside := &Orthotope[int32]{Point: Coordinate[int32]{30000, 30000, 20000}}
+	outside := &Orthotope[int32]{Point: Coordinate[int32]{40000, 40000, 0}}
+	far := &Orthotope[int32]{Point: Coordinate[int32]{-2000000000, 2000000000, 2000000000}}
+	orths := []*Orthotope[int32]{near, inside, outside, far}
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Iterator()
+
+	check := func(radius int32, expected ...*Orthotope[int32]) {
+		want := map[*Orthotope[int32]]bool{}
+		for _, orth := range expected {
+			want[orth] = true
+		}
+		found := map[*Orthotope[int32]]bool{}
+		iter.WithinRadius(Coordinate[int32]{}, radius, func(orth *Orthotope[int32]) bool {
+			found[orth] = true
+			return true
+		})
+		count := 0
+		iter.AllWithinRadius([]Coordinate[int32]{{}}, radius, func(_ int, orth *Orthotope[int32]) {
+			if !found[orth] {
+				t.Errorf("AllWithinRadius found %v within %d, WithinRadius did not", orth.String(), radius)
+			}
+			count++
+		})
+		if count != len(found) {
+			t.Errorf("AllWithinRadius found %d within %d, expected %d", count, radius, len(found))
+		}
+		for _, orth := range orths {
+			if found[orth] != want[orth] {
+				t.Errorf("Found %v within %d: %t, expected %t", orth.String(), radius, found[orth], want[orth])
+			}
+		}
+	}
+	check(50000, near, inside)
+	check(math.MaxInt32, near, inside, outside)
+}
+
+func TestAllWithinRadius(t *testing.T) {
+	orths := randomOrths(2000)
+	centers := make([]Coordinate[int32], len(orths))
+	for i, orth := range orths {
+		orth.Delta = Coordinate[int32]{}
+		centers[i] = orth.Point
+	}
+	iter := LinearBVH[*Orthotope[int32], int32](orths).Iterator()
+
+	neighbours := make([]map[*Orthotope[int32]]bool, len(centers))
+	for i := range neighbours {
+		neighbours[i] = map[*Orthotope[int32]]bool{}
+	}
+	iter.AllWithinRadius(centers, 60, func(index int, neighbour *Orthotope[int32]) {
+		if neighbours[index][neighbour] {
+			t.Errorf("Found %v twice for %v", neighbour.String(), centers[index])
+		}
+		neighbours[index][neighbour] = true
+	})
+	for i, center := range centers {
+		count := 0
+		iter.WithinRadius(center, 60, func(orth *Orthotope[int32]) bool {
+			if !neighbours[i][orth] {
+				t.Errorf("Did not find %v within 60 of %v", orth.String(), center)
+			}
+			count++
+			return true
+		})
+		if count != len(neighbours[i]) {
+			t.Errorf("Found %d neighbours of %v, expected %d", len(neighbours[i]), center, count)
+		}
+	}
+}
+
+// denseOrths returns points packed closely enough to have several neighbours within 10, like particles.
+func denseOrths() []*Orthotope[int32] {
+	orths := randomOrths(10000)
+	for _, orth := range orths {
+		orth.Point = Coordinate[int32]{orth.Point[0] / 5, orth.Point[1] / 5, orth.Point[2] / 5}
+		orth.Delta = Coordinate[int32]{}
+	}
+	return orths
+}
+
+func BenchmarkWithinRadius(b *testing.B) {
+	orths := denseOrths()
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Iterator()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, orth := range orths {
+			iter.WithinRadius(orth.Point, 10, func(*Orthotope[int32]) bool { return true })
+		}
+	}
+}
+
+func BenchmarkAllWithinRadius(b *testing.B) {
+	orths := denseOrths()
+	centers := make([]Coordinate[int32], len(orths))
+	for i, orth := range orths {
+		centers[i] = orth.Point
+	}
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Iterator()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		iter.AllWithinRadius(centers, 10, func(int, *Orthotope[int32]) {})
+	}
+}
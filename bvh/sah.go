@@ -0,0 +1,202 @@
+package collision
+
+import (
+	"math"
+	"sort"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// SAHBins is the number of bins used per dimension by BinnedSAHBVH.
+const SAHBins int = 16
+
+// sahBin accumulates the bounds and count of the volumes whose centers fall within it.
+type sahBin[E math32.Number] struct {
+	min   math32.Coordinate[E]
+	max   math32.Coordinate[E]
+	count int
+}
+
+// grow expands the bin so that it contains the bounds of vol.
+func (b *sahBin[E]) grow(point, delta math32.Coordinate[E]) {
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		if b.count == 0 || point[d] < b.min[d] {
+			b.min[d] = point[d]
+		}
+		if b.count == 0 || point[d]+delta[d] > b.max[d] {
+			b.max[d] = point[d] + delta[d]
+		}
+	}
+	b.count++
+}
+
+// merge expands the bin so that it contains the other bin.
+func (b *sahBin[E]) merge(other *sahBin[E]) {
+	if other.count == 0 {
+		return
+	}
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		if b.count == 0 || other.min[d] < b.min[d] {
+			b.min[d] = other.min[d]
+		}
+		if b.count == 0 || other.max[d] > b.max[d] {
+			b.max[d] = other.max[d]
+		}
+	}
+	b.count += other.count
+}
+
+// score mirrors Orthotope.Score (the sum of the edges) for the bounds of the bin.
+func (b *sahBin[E]) score() E {
+	var score E
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		score += b.max[d] - b.min[d]
+	}
+	return score
+}
+
+// center of a volume, used to place it into a bin.
+func center[E math32.Number](vol math32.VolumeType[E], dim int) E {
+	return vol.GetPoint()[dim] + vol.GetDelta()[dim]/2
+}
+
+// BinnedSAHBVH creates a BVH top down by choosing the split plane with the lowest surface area cost. Volumes are
+// placed into SAHBins bins by their centers, so each level takes linear time. Once a branch holds medianSize volumes
+// or fewer, it is split at the median instead, which is cheaper for small branches. Every leaf still holds a single
+// volume. Since the cheapest splits may be uneven, the tree is then rebalanced such that it can be used with Add and
+// Remove.
+func BinnedSAHBVH[T math32.VolumeType[E], E math32.Number](orths []T, medianSize int) *BVol[T, E] {
+	if len(orths) == 0 {
+		return &BVol[T, E]{}
+	}
+	sorted := make([]T, len(orths))
+	copy(sorted, orths)
+	tree := binnedSAH[T, E](sorted, medianSize)
+	tree.rebalance()
+	return tree
+}
+
+// binnedSAH recursively partitions orths in place.
+func binnedSAH[T math32.VolumeType[E], E math32.Number](orths []T, medianSize int) *BVol[T, E] {
+	if len(orths) == 1 {
+		return newLeaf[T, E](orths[0], DefaultLayer)
+	}
+
+	mid := -1
+	if len(orths) > medianSize {
+		mid = sahPartition[T, E](orths)
+	}
+	if mid <= 0 || mid >= len(orths) {
+		mid = medianPartition[T, E](orths)
+	}
+
+	return joinBVol(binnedSAH[T, E](orths[:mid], medianSize), binnedSAH[T, E](orths[mid:], medianSize))
+}
+
+// joinBVol creates a parent volume for two branches.
+func joinBVol[T math32.VolumeType[E], E math32.Number](first, second *BVol[T, E]) *BVol[T, E] {
+	bvol := &BVol[T, E]{vol: first.vol.New().(T), desc: [2]*BVol[T, E]{first, second}}
+	bvol.redepth()
+	bvol.minBound()
+	return bvol
+}
+
+// centerBounds returns the range of the centers of orths.
+func centerBounds[T math32.VolumeType[E], E math32.Number](orths []T) (math32.Coordinate[E], math32.Coordinate[E]) {
+	var low, high math32.Coordinate[E]
+	for i, orth := range orths {
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			c := center[E](orth, d)
+			if i == 0 || c < low[d] {
+				low[d] = c
+			}
+			if i == 0 || c > high[d] {
+				high[d] = c
+			}
+		}
+	}
+	return low, high
+}
+
+// binIndex maps a center within [low, low+extent] onto one of the SAHBins bins.
+func binIndex[E math32.Number](c, low, extent E) int {
+	index := int(float64(c-low) / float64(extent) * float64(SAHBins))
+	if index >= SAHBins {
+		return SAHBins - 1
+	}
+	return index
+}
+
+// sahPartition reorders orths around the cheapest bin boundary and returns the split index, or -1 if none is found.
+func sahPartition[T math32.VolumeType[E], E math32.Number](orths []T) int {
+	low, high := centerBounds[T, E](orths)
+
+	bestDim, bestBin := -1, 0
+	bestCost := math.MaxFloat64
+
+	var bins [SAHBins]sahBin[E]
+	var right [SAHBins]sahBin[E]
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		extent := high[d] - low[d]
+		if extent <= 0 {
+			continue
+		}
+		bins = [SAHBins]sahBin[E]{}
+		for _, orth := range orths {
+			bins[binIndex(center[E](orth, d), low[d], extent)].grow(orth.GetPoint(), orth.GetDelta())
+		}
+
+		// Sweep from the right to accumulate the cost of the right side for each boundary.
+		right[SAHBins-1] = bins[SAHBins-1]
+		for b := SAHBins - 2; b > 0; b-- {
+			right[b] = right[b+1]
+			right[b].merge(&bins[b])
+		}
+
+		// Sweep from the left comparing the cost of each boundary.
+		left := sahBin[E]{}
+		for b := 0; b < SAHBins-1; b++ {
+			left.merge(&bins[b])
+			if left.count == 0 || right[b+1].count == 0 {
+				continue
+			}
+			cost := float64(left.score())*float64(left.count) +
+				float64(right[b+1].score())*float64(right[b+1].count)
+			if cost < bestCost {
+				bestCost = cost
+				bestDim = d
+				bestBin = b
+			}
+		}
+	}
+
+	if bestDim < 0 {
+		return -1
+	}
+
+	// Partition in place such that every volume left of the boundary comes first.
+	extent := high[bestDim] - low[bestDim]
+	mid := 0
+	for i, orth := range orths {
+		if binIndex(center[E](orth, bestDim), low[bestDim], extent) <= bestBin {
+			orths[i], orths[mid] = orths[mid], orths[i]
+			mid++
+		}
+	}
+	return mid
+}
+
+// medianPartition sorts orths along the dimension with the widest spread of centers and returns the middle index.
+func medianPartition[T math32.VolumeType[E], E math32.Number](orths []T) int {
+	low, high := centerBounds[T, E](orths)
+	dim := 0
+	for d := 1; d < math32.DIMENSIONS; d++ {
+		if high[d]-low[d] > high[dim]-low[dim] {
+			dim = d
+		}
+	}
+	sort.Slice(orths, func(i, j int) bool {
+		return center[E](orths[i], dim) < center[E](orths[j], dim)
+	})
+	return len(orths) / 2
+}
@@ -0,0 +1,117 @@
+package collision
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/briannoyama/bvh/math32"
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestBinnedSAHBVH(t *testing.T) {
+	orths := make([]*math32.Orthotope[float32], len(leaf))
+	copy(orths, leaf[:])
+	tree := BinnedSAHBVH[*math32.Orthotope[float32], float32](orths, 1)
+	checkBalance(t, tree)
+	if tree.Score() > 262 {
+		t.Errorf("Inefficient BVH created via BinnedSAH:\n%v", tree.String())
+	}
+	if tree.GetDepth() < 4 {
+		t.Errorf("Unexpected depth: %d\nTree:\n%v", tree.GetDepth(), tree.String())
+	}
+	for i, orth := range orths {
+		if orth != leaf[i] {
+			t.Errorf("BinnedSAHBVH reordered the input volumes.")
+		}
+	}
+
+	iter := tree.Iterator()
+	for _, orth := range leaf {
+		if !iter.Contains(orth) {
+			t.Errorf("Unable to find: %v\n", orth.String())
+		}
+	}
+}
+
+func TestBinnedSAHBVHAddRemove(t *testing.T) {
+	orths := randomOrths(500)
+	tree := BinnedSAHBVH[*Orthotope[int32], int32](orths[:400], 4)
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+
+	for _, orth := range orths[400:] {
+		if !tree.Add(orth) {
+			t.Errorf("Unable to add: %v\n", orth.String())
+		}
+	}
+	for _, orth := range orths[:250] {
+		if !tree.Remove(orth) {
+			t.Errorf("Unable to remove: %v\n", orth.String())
+		}
+	}
+	checkBounds(t, tree)
+
+	iter := tree.Iterator()
+	for i, orth := range orths {
+		if iter.Contains(orth) != (i >= 250) {
+			t.Errorf("Unexpected membership for %v\n", orth.String())
+		}
+	}
+}
+
+func TestBinnedSAHBVHBalance(t *testing.T) {
+	// A cluster of small volumes is cheaper to split off than to divide evenly.
+	orths := randomOrths(2000)
+	for _, orth := range orths[:1500] {
+		orth.Point = [DIMENSIONS]int32{orth.Point[0] % 50, orth.Point[1] % 50, orth.Point[2] % 50}
+		orth.Delta = [DIMENSIONS]int32{1, 1, 1}
+	}
+	tree := BinnedSAHBVH[*Orthotope[int32], int32](orths, 4)
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if tree.count != int32(len(orths)) {
+		t.Errorf("Expected %d volumes, got %d", len(orths), tree.count)
+	}
+}
+
+func TestBinnedSAHBVHEmpty(t *testing.T) {
+	tree := BinnedSAHBVH[*Orthotope[float32], float32](nil, 4)
+	if !tree.Add(leaf[0]) || !tree.Iterator().Contains(leaf[0]) {
+		t.Errorf("Unable to add to an empty BinnedSAH tree.")
+	}
+}
+
+// checkBounds verifies that every volume contains its descendants and has a consistent depth.
+func checkBounds[T math32.VolumeType[E], E math32.Number](t *testing.T, tree *BVol[T, E]) {
+	t.Helper()
+	iter := tree.Iterator()
+	for iter.HasNext() {
+		next := iter.Next()
+		if next.depth == 0 {
+			continue
+		}
+		for _, child := range next.desc {
+			if !next.vol.Contains(child.vol) {
+				t.Errorf("%v does not contain child %v\n", next.vol.String(), child.vol.String())
+			}
+		}
+		if next.depth != math32.Int32Max(next.desc[0].depth, next.desc[1].depth)+1 {
+			t.Errorf("Inconsistent depth for %v\n", next.vol.String())
+		}
+	}
+}
+
+func BenchmarkBinnedSAHBVH(b *testing.B) {
+	r := rand.New(rand.NewSource(7))
+	orths := make([]*Orthotope[float32], 10000)
+	for i := range orths {
+		orths[i] = &Orthotope[float32]{
+			Point: Coordinate[float32]{r.Float32() * 1000, r.Float32() * 1000, r.Float32() * 1000},
+			Delta: Coordinate[float32]{r.Float32() * 20, r.Float32() * 20, r.Float32() * 20},
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BinnedSAHBVH[*Orthotope[float32], float32](orths, 4)
+	}
+}
@@ -0,0 +1,89 @@
+package collision
+
+import (
+	"math/rand"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Len returns the number of volumes stored in the BVH.
+func (b *BVol[T, E]) Len() int {
+	return int(b.count)
+}
+
+// CountOverlapping returns the number of volumes that overlap q. Branches contained by q are counted without being
+// visited. The stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) CountOverlapping(q T) int {
+	count := 0
+	s.overlapping(q, func(bvol *BVol[T, E]) bool {
+		count += int(bvol.count)
+		return true
+	})
+	return count
+}
+
+// Sample returns a volume chosen uniformly at random from the BVH, or nil when it is empty.
+func (s *orthStack[T, E]) Sample(rng *rand.Rand) T {
+	if s.bvh.count == 0 {
+		var zero T
+		return zero
+	}
+	return nthLeaf(s.bvh, rng.Int31n(s.bvh.count))
+}
+
+// SampleIn returns a volume chosen uniformly at random from those that overlap q, or nil when there are none. The
+// stack is emptied; call Reset before further queries.
+func (s *orthStack[T, E]) SampleIn(q T, rng *rand.Rand) T {
+	var sample T
+	count := s.CountOverlapping(q)
+	if count == 0 {
+		return sample
+	}
+
+	// Visit the branches in the same order as when counting, until reaching the chosen volume.
+	n := rng.Int31n(int32(count))
+	s.overlapping(q, func(bvol *BVol[T, E]) bool {
+		if n < bvol.count {
+			sample = nthLeaf(bvol, n)
+			return false
+		}
+		n -= bvol.count
+		return true
+	})
+	return sample
+}
+
+// overlapping calls found for each leaf that overlaps q and each branch contained by q, in pre-order, until found
+// returns false.
+func (s *orthStack[T, E]) overlapping(q T, found func(*BVol[T, E]) bool) {
+	s.Reset()
+	for s.HasNext() {
+		bvol, _ := s.pop()
+		if bvol.count == 0 || !bvol.vol.Overlaps(q) {
+			continue
+		}
+		if bvol.depth == 0 || q.Contains(bvol.vol) {
+			if !found(bvol) {
+				s.bvStack = s.bvStack[:0]
+				s.intStack = s.intStack[:0]
+				return
+			}
+			continue
+		}
+		s.append(bvol.desc[1], 0)
+		s.append(bvol.desc[0], 0)
+	}
+}
+
+// nthLeaf returns the nth volume below bvol in pre-order, using the counts to skip branches.
+func nthLeaf[T math32.VolumeType[E], E math32.Number](bvol *BVol[T, E], n int32) T {
+	for bvol.depth > 0 {
+		if n < bvol.desc[0].count {
+			bvol = bvol.desc[0]
+		} else {
+			n -= bvol.desc[0].count
+			bvol = bvol.desc[1]
+		}
+	}
+	return bvol.vol
+}
@@ -0,0 +1,104 @@
+package collision
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkCounts verifies that each volume counts the leaves below it.
+func checkCounts[T VolumeType[E], E Number](t *testing.T, tree *BVol[T, E]) {
+	iter := tree.Iterator()
+	for iter.HasNext() {
+		next := iter.Next()
+		if next.depth == 0 && next.count != 1 && !next.vol.IsNil() {
+			t.Errorf("Leaf %v has count %d", next.vol.String(), next.count)
+		}
+		if next.depth > 0 && next.count != next.desc[0].count+next.desc[1].count {
+			t.Errorf("Volume %v has count %d", next.vol.String(), next.count)
+		}
+	}
+}
+
+func TestCountOverlapping(t *testing.T) {
+	orths := randomOrths(1000)
+	tree := LinearBVH[*Orthotope[int32], int32](orths[:600])
+	checkCounts(t, tree)
+	iter := NewArena[*Orthotope[int32], int32](0).Iterator(tree)
+	for _, orth := range orths[600:] {
+		iter.Add(orth)
+	}
+	for _, orth := range orths[:300] {
+		iter.Remove(orth)
+	}
+	checkCounts(t, tree)
+	if tree.Len() != 700 {
+		t.Errorf("Expected 700 volumes, got %d", tree.Len())
+	}
+
+	for _, q := range randomOrths(100) {
+		q.Delta = Coordinate[int32](q.Delta).Scale(10)
+		expected := 0
+		iter.Reset()
+		for found := iter.Query(q); found != nil; found = iter.Query(q) {
+			expected++
+		}
+		if count := iter.CountOverlapping(q); count != expected {
+			t.Errorf("Counted %d volumes overlapping %v, expected %d", count, q.String(), expected)
+		}
+	}
+
+	empty := (&BVol[*Orthotope[int32], int32]{}).Iterator()
+	if count := empty.CountOverlapping(orths[0]); count != 0 {
+		t.Errorf("Counted %d volumes in an empty hierarchy", count)
+	}
+}
+
+func TestSample(t *testing.T) {
+	orths := randomOrths(20)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	for _, orth := range orths {
+		tree.Add(orth)
+	}
+	iter := tree.Iterator()
+	rng := rand.New(rand.NewSource(5))
+
+	counts := map[*Orthotope[int32]]int{}
+	for i := 0; i < 20000; i++ {
+		counts[iter.Sample(rng)]++
+	}
+	for _, orth := range orths {
+		if counts[orth] < 800 || counts[orth] > 1200 {
+			t.Errorf("Sampled %v %d times out of 20000, expected about 1000", orth.String(), counts[orth])
+		}
+	}
+
+	q := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{500, 1000, 1000}}
+	var inside []*Orthotope[int32]
+	for _, orth := range orths {
+		if orth.Overlaps(q) {
+			inside = append(inside, orth)
+		}
+	}
+	counts = map[*Orthotope[int32]]int{}
+	for i := 0; i < 1000*len(inside); i++ {
+		counts[iter.SampleIn(q, rng)]++
+	}
+	if len(counts) != len(inside) {
+		t.Errorf("Sampled %d different volumes, expected %d", len(counts), len(inside))
+	}
+	for _, orth := range inside {
+		if counts[orth] < 800 || counts[orth] > 1200 {
+			t.Errorf("Sampled %v %d times, expected about 1000", orth.String(), counts[orth])
+		}
+	}
+
+	far := &Orthotope[int32]{Point: Coordinate[int32]{5000, 5000, 5000}, Delta: Coordinate[int32]{1, 1, 1}}
+	if sample := iter.SampleIn(far, rng); sample != nil {
+		t.Errorf("Sampled %v outside of the hierarchy", sample.String())
+	}
+	if sample := (&BVol[*Orthotope[int32], int32]{}).Iterator().Sample(rng); sample != nil {
+		t.Errorf("Sampled %v from an empty hierarchy", sample.String())
+	}
+}
@@ -0,0 +1,256 @@
+package collision
+
+import (
+	"sync"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// txnOp is a change staged by a Txn. The removed volume, if any, is removed before the added volume is added; an
+// update does both, and the added volume takes the layers and priority of the removed one.
+type txnOp[T math32.VolumeType[E], E math32.Number] struct {
+	remove T
+	add    T
+	layers uint64
+}
+
+// Txn stages additions, removals and updates to a BVH, so that they are applied all at once or not at all (see
+// BVol.Begin). A Txn is not thread-safe.
+type Txn[T math32.VolumeType[E], E math32.Number] struct {
+	bvh  *BVol[T, E]
+	lock sync.Locker
+	ops  []txnOp[T, E]
+	done bool
+
+	// The tree being built by Commit. Nodes of the current generation were copied or created by it.
+	root    *BVol[T, E]
+	cow     cowState
+	scratch T
+}
+
+// Begin starts a transaction. Nothing is applied to the BVH until Commit, and then only if every staged change
+// succeeds, so the BVH is never left with part of a batch.
+func (b *BVol[T, E]) Begin() *Txn[T, E] {
+	return &Txn[T, E]{bvh: b}
+}
+
+// Begin starts a transaction on the BVH. Commit holds the write lock while the changes are applied, so queries from
+// other goroutines observe either none or all of them.
+func (c *ConcurrentBVol[T, E]) Begin() *Txn[T, E] {
+	return &Txn[T, E]{bvh: &c.root, lock: &c.lock}
+}
+
+// Add stages the addition of an orth in the DefaultLayer.
+func (t *Txn[T, E]) Add(orth T) {
+	t.AddLayers(orth, DefaultLayer)
+}
+
+// AddLayers stages the addition of an orth in the given layers.
+func (t *Txn[T, E]) AddLayers(orth T, layers uint64) {
+	t.ops = append(t.ops, txnOp[T, E]{add: orth, layers: layers})
+}
+
+// Remove stages the removal of an orth.
+func (t *Txn[T, E]) Remove(orth T) {
+	t.ops = append(t.ops, txnOp[T, E]{remove: orth})
+}
+
+// Update stages replacing old with updated, which keeps the layers and priority of old. Volumes should be replaced
+// rather than moved in place, since a moved volume cannot be restored by Rollback.
+func (t *Txn[T, E]) Update(old, updated T) {
+	t.ops = append(t.ops, txnOp[T, E]{remove: old, add: updated})
+}
+
+// Len returns the number of staged changes.
+func (t *Txn[T, E]) Len() int {
+	return len(t.ops)
+}
+
+// Rollback discards the staged changes, leaving the BVH exactly as it was. It does nothing after Commit.
+func (t *Txn[T, E]) Rollback() {
+	t.ops, t.done = nil, true
+}
+
+// Commit applies the staged changes in order. They are applied to copies of the nodes they modify, and each modified
+// node is rebalanced once, children first, rather than after every change. The copy then replaces the root of the
+// BVH. If a change fails, with the errors of Insert and Delete, the BVH is left exactly as it was and the error is
+// returned. Returns ErrTxnDone if the transaction was already committed or rolled back.
+func (t *Txn[T, E]) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+	ops := t.ops
+	t.Rollback()
+	if len(ops) == 0 {
+		return nil
+	}
+	if t.lock != nil {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+	}
+
+	// Every node of the BVH is from an older generation than the root, so none of them are modified.
+	t.cow = cowState{gen: t.bvh.gen + 1}
+	t.root = copyNode(&t.cow, t.bvh)
+	defer func() {
+		t.root = nil
+	}()
+	for _, op := range ops {
+		layers, priority := op.layers, int32(0)
+		if !op.remove.IsNil() {
+			leaf, err := t.remove(op.remove)
+			if err != nil {
+				return err
+			}
+			layers, priority = leaf.layers(), leaf.priority()
+		}
+		if !op.add.IsNil() {
+			if err := t.add(op.add, layers, priority); err != nil {
+				return err
+			}
+		} else if op.remove.IsNil() {
+			return validVolume(op.add)
+		}
+	}
+
+	t.rebalance(t.root)
+	root := *t.root
+	root.gen = t.cow.gen
+	*t.bvh = root
+	return nil
+}
+
+// path finds the leaf for o in the tree being built, and copies the nodes above its parent. The stack holds the path
+// from the root to the leaf. Returns nil if o was not found.
+func (t *Txn[T, E]) path(o T) *orthStack[T, E] {
+	s := t.root.Iterator()
+	bvol := s.path(o)
+	if bvol == nil || bvol.depth > 0 || !bvol.vol.Equals(o) {
+		return nil
+	}
+	for i := 0; i < len(s.bvStack)-2; i++ {
+		owned := copyNode(&t.cow, s.bvStack[i])
+		if i == 0 {
+			t.root = owned
+		} else {
+			s.bvStack[i-1].desc[s.intStack[i-1]] = owned
+		}
+		s.bvStack[i] = owned
+	}
+	return s
+}
+
+// remove an orth from the tree being built, without rebalancing. Returns the removed leaf.
+func (t *Txn[T, E]) remove(o T) (*BVol[T, E], error) {
+	if t.root.vol.IsNil() {
+		return nil, ErrEmptyTree
+	}
+	if o.IsNil() {
+		return nil, ErrNotFound
+	}
+	s := t.path(o)
+	if s == nil {
+		return nil, ErrNotFound
+	}
+
+	last := len(s.bvStack) - 1
+	leaf := s.bvStack[last]
+	switch last {
+	case 0:
+		// Removing the only volume leaves an empty root.
+		root := &BVol[T, E]{gen: t.cow.gen}
+		if aug := leaf.aug(); aug != nil {
+			root.setAug(aug.clone())
+			root.aug().reaggregate(root)
+		}
+		t.root = root
+	case 1:
+		t.root = t.root.desc[s.intStack[0]^1]
+	default:
+		parent, gParent := s.bvStack[last-1], s.bvStack[last-2]
+		gParent.desc[s.intStack[last-2]] = parent.desc[s.intStack[last-1]^1]
+	}
+	return leaf, nil
+}
+
+// add an orth to the tree being built, without rebalancing. The bounds on the way to the new leaf are enlarged so
+// that later changes in the same transaction may find it.
+func (t *Txn[T, E]) add(orth T, layers uint64, priority int32) error {
+	if err := validVolume(orth); err != nil {
+		return err
+	}
+	if t.root.Iterator().Contains(orth) {
+		return ErrDuplicate
+	}
+
+	leaf := newLeaf[T, E](orth, layers)
+	leaf.setPriority(priority)
+	if t.root.vol.IsNil() {
+		leaf.gen = t.cow.gen
+		if aug := t.root.aug(); aug != nil {
+			leaf.setAug(aug.clone())
+			leaf.aug().reaggregate(leaf)
+		}
+		t.root = leaf
+		return nil
+	}
+	if t.scratch.IsNil() {
+		t.scratch = orth.New().(T)
+	}
+
+	var parent *BVol[T, E]
+	index := 0
+	next := t.root
+	if next.depth > 0 {
+		next = copyNode(&t.cow, next)
+		t.root = next
+	}
+	for next.depth > 0 {
+		minBoundsPair(t.scratch, next.vol, orth)
+		next.vol.MinBounds(t.scratch)
+
+		smallestScore := math32.MaxValue[E]()
+		for i, child := range next.desc {
+			minBoundsPair(t.scratch, orth, child.vol)
+			if score := t.scratch.Score() - child.vol.Score(); score < smallestScore {
+				index, smallestScore = i, score
+			}
+		}
+		parent = next
+		next = next.desc[index]
+		if next.depth > 0 {
+			next = copyNode(&t.cow, next)
+			parent.desc[index] = next
+		}
+	}
+
+	// Pair the new leaf with the leaf that was found, which is not modified.
+	branch := &BVol[T, E]{vol: orth.New().(T), desc: [2]*BVol[T, E]{leaf, next}, depth: 1, gen: t.cow.gen}
+	if aug := next.aug(); aug != nil {
+		leaf.setAug(aug.clone())
+		branch.setAug(aug.clone())
+		leaf.aug().reaggregate(leaf)
+	}
+	branch.minBound()
+	if parent == nil {
+		t.root = branch
+	} else {
+		parent.desc[index] = branch
+	}
+	return nil
+}
+
+// rebalance the nodes below bvol that were copied or created by the transaction, children first.
+func (t *Txn[T, E]) rebalance(bvol *BVol[T, E]) {
+	if bvol.depth == 0 || bvol.gen != t.cow.gen {
+		return
+	}
+	t.rebalance(bvol.desc[0])
+	t.rebalance(bvol.desc[1])
+	t.balance(bvol)
+}
+
+// balance restores the balance of bvol (see balanceBVol), copying the nodes it modifies.
+func (t *Txn[T, E]) balance(bvol *BVol[T, E]) {
+	balanceBVol(bvol, func(child *BVol[T, E]) *BVol[T, E] { return copyNode(&t.cow, child) })
+}
@@ -0,0 +1,260 @@
+package collision
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/briannoyama/bvh/math32"
+)
+
+// checkBalance verifies that the children of every volume differ in depth by at most one.
+func checkBalance[T VolumeType[E], E Number](t *testing.T, tree *BVol[T, E]) {
+	t.Helper()
+	iter := tree.Iterator()
+	for iter.HasNext() {
+		next := iter.Next()
+		if next.depth > 0 && Int32Abs(next.desc[0].depth-next.desc[1].depth) > 1 {
+			t.Errorf("Unbalanced volume %v with depths %d and %d", next.vol.String(), next.desc[0].depth,
+				next.desc[1].depth)
+		}
+	}
+}
+
+// moved returns a copy of orth moved along every axis.
+func moved(orth *Orthotope[int32], by int32) *Orthotope[int32] {
+	return &Orthotope[int32]{Point: Coordinate[int32](orth.Point).Add(FillCoordinate(by)), Delta: orth.Delta}
+}
+
+func TestTxnCommit(t *testing.T) {
+	orths := randomOrths(3000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	agg := Augment(tree, massAggregator)
+	for _, orth := range orths[:1000] {
+		tree.AddLayers(orth, 2)
+	}
+	iter := tree.Iterator()
+	iter.SetPriority(orths[0], 5)
+
+	txn := tree.Begin()
+	updated := moved(orths[0], 10)
+	txn.Update(orths[0], updated)
+	for _, orth := range orths[100:400] {
+		txn.Remove(orth)
+	}
+	for _, orth := range orths[1000:] {
+		txn.Add(orth)
+	}
+	// Changes may depend on earlier changes in the same transaction.
+	txn.Remove(orths[2999])
+	txn.Add(orths[100])
+	if txn.Len() != 2303 {
+		t.Errorf("Expected 2303 staged changes, got %d", txn.Len())
+	}
+	if !iter.Contains(orths[0]) || iter.Contains(orths[1000]) || tree.Len() != 1000 {
+		t.Errorf("Staged changes were applied before Commit")
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Unable to commit: %v", err)
+	}
+
+	for index, orth := range orths {
+		expected := index > 0 && (index < 100 || index >= 400) && index != 2999 || index == 100
+		if iter.Contains(orth) != expected {
+			t.Errorf("Unexpected membership for %d: %v", index, orth.String())
+		}
+	}
+	if !iter.Contains(updated) || iter.Priority(updated) != 5 || iter.Layers(updated) != 2 {
+		t.Errorf("The update did not keep the priority and layers of %v", orths[0].String())
+	}
+	if tree.Len() != 2700 || agg.Value()[0] != 2700 {
+		t.Errorf("Expected 2700 volumes, got %d and an aggregate of %v", tree.Len(), agg.Value())
+	}
+	checkBounds(t, tree)
+	checkCounts(t, tree)
+	checkBalance(t, tree)
+	checkAggregate(t, agg, tree)
+
+	// Later changes use the usual methods.
+	for _, orth := range orths[400:500] {
+		if !tree.Remove(orth) {
+			t.Errorf("Unable to remove %v after committing", orth.String())
+		}
+	}
+	checkBounds(t, tree)
+	checkBalance(t, tree)
+	if err := txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone when committing twice, got %v", err)
+	}
+}
+
+func TestTxnRollback(t *testing.T) {
+	orths := randomOrths(600)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	for _, orth := range orths[:500] {
+		tree.Add(orth)
+	}
+	expected := tree.String()
+
+	txn := tree.Begin()
+	for _, orth := range orths[:250] {
+		txn.Remove(orth)
+	}
+	txn.Add(orths[550])
+	txn.Rollback()
+	if err := txn.Commit(); !errors.Is(err, ErrTxnDone) {
+		t.Errorf("Expected ErrTxnDone after Rollback, got %v", err)
+	}
+	if tree.String() != expected {
+		t.Errorf("Rollback modified the tree")
+	}
+
+	// A failing change leaves the tree exactly as it was, even after the changes before it.
+	failures := []struct {
+		stage func(txn *Txn[*Orthotope[int32], int32])
+		err   error
+	}{
+		{func(txn *Txn[*Orthotope[int32], int32]) { txn.Remove(orths[0]) }, ErrNotFound},
+		{func(txn *Txn[*Orthotope[int32], int32]) { txn.Add(orths[300]) }, ErrDuplicate},
+		{func(txn *Txn[*Orthotope[int32], int32]) { txn.Add(nil) }, ErrInvalidVolume},
+		{func(txn *Txn[*Orthotope[int32], int32]) {
+			txn.Update(orths[301], &Orthotope[int32]{Delta: Coordinate[int32]{-1}})
+		},
+			ErrInvalidVolume},
+		{func(txn *Txn[*Orthotope[int32], int32]) { txn.Add(orths[500]) }, ErrDuplicate},
+	}
+	for _, failure := range failures {
+		txn := tree.Begin()
+		for _, orth := range orths[:250] {
+			txn.Remove(orth)
+		}
+		for _, orth := range orths[500:] {
+			txn.Add(orth)
+		}
+		failure.stage(txn)
+		if err := txn.Commit(); !errors.Is(err, failure.err) {
+			t.Errorf("Expected %v, got %v", failure.err, err)
+		}
+		if tree.String() != expected || tree.Len() != 500 {
+			t.Errorf("A failed commit modified the tree")
+		}
+	}
+
+	// Removing every volume leaves an empty tree.
+	txn = tree.Begin()
+	for _, orth := range orths[:500] {
+		txn.Remove(orth)
+	}
+	if err := txn.Commit(); err != nil || !tree.vol.IsNil() || tree.Len() != 0 {
+		t.Errorf("Expected an empty tree: %v", err)
+	}
+	txn = tree.Begin()
+	txn.Remove(orths[0])
+	if err := txn.Commit(); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Expected ErrEmptyTree, got %v", err)
+	}
+}
+
+func TestTxnEqualBounds(t *testing.T) {
+	a := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{1, 1, 1}}
+	b := &Orthotope[int32]{Point: Coordinate[int32]{2, 2, 2}, Delta: Coordinate[int32]{1, 1, 1}}
+	c := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{3, 3, 3}}
+	tree := &BVol[*Orthotope[int32], int32]{}
+	tree.Add(a)
+	tree.Add(b)
+
+	// c has the bounds of the root, so removing it before it is added fails.
+	txn := tree.Begin()
+	txn.Remove(c)
+	if err := txn.Commit(); !errors.Is(err, ErrNotFound) || tree.Len() != 2 {
+		t.Errorf("Expected ErrNotFound and 2 volumes, got %v and %d", err, tree.Len())
+	}
+	txn = tree.Begin()
+	txn.Add(c)
+	if err := txn.Commit(); err != nil || !tree.Iterator().Contains(c) {
+		t.Fatalf("Unable to add %v: %v", c.String(), err)
+	}
+	txn = tree.Begin()
+	txn.Remove(c)
+	if err := txn.Commit(); err != nil || tree.Iterator().Contains(c) || tree.Len() != 2 {
+		t.Errorf("Unable to remove %v: %v", c.String(), err)
+	}
+	checkBounds(t, tree)
+}
+
+func TestConcurrentTxn(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := NewConcurrentBVol[*Orthotope[int32], int32]()
+	for _, orth := range orths[:1000] {
+		tree.Add(orth)
+	}
+
+	// Each transaction swaps one half of the volumes for the other, so readers should always find 1000.
+	everything := &Orthotope[int32]{Point: FillCoordinate[int32](-100), Delta: FillCoordinate[int32](2000)}
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				count := 0
+				tree.Query(everything, func(*Orthotope[int32]) bool {
+					count++
+					return true
+				})
+				if count != 1000 {
+					t.Errorf("Observed %d volumes during a transaction", count)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		current, next := orths[:1000], orths[1000:]
+		if i%2 == 1 {
+			current, next = next, current
+		}
+		txn := tree.Begin()
+		for index := range current {
+			txn.Remove(current[index])
+			txn.Add(next[index])
+		}
+		if err := txn.Commit(); err != nil {
+			t.Fatalf("Unable to commit: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	checkBounds(t, &tree.root)
+	checkBalance(t, &tree.root)
+	// The writer continues to work after a transaction.
+	if err := tree.Add(moved(orths[0], 5000)); err != nil {
+		t.Errorf("Unable to add after a transaction: %v", err)
+	}
+}
+
+func BenchmarkTxnCommit(b *testing.B) {
+	orths := randomOrths(11000)
+	tree := &BVol[*Orthotope[int32], int32]{}
+	for _, orth := range orths[:10000] {
+		tree.Add(orth)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		current, next := orths[:1000], orths[10000:]
+		if i%2 == 1 {
+			current, next = next, current
+		}
+		txn := tree.Begin()
+		for index := range current {
+			txn.Update(current[index], next[index])
+		}
+		txn.Commit()
+	}
+}
@@ -0,0 +1,256 @@
+package collision
+
+import (
+	"github.com/briannoyama/bvh/math32"
+)
+
+// WIDTH is the maximum number of children of each node in a BVH4.
+const WIDTH int = 4
+
+// wideNode stores the bounds of up to WIDTH children as a structure of arrays, so that a single pass over the
+// dimensions tests every child.
+type wideNode[E math32.Number] struct {
+	min   [math32.DIMENSIONS][WIDTH]E
+	max   [math32.DIMENSIONS][WIDTH]E
+	child [WIDTH]int32 // Index into BVH4.nodes, or -(index+1) into BVH4.vols for leaves.
+	count int32
+}
+
+// BVH4 is an immutable copy of a BVol (see BVol.Collapse) in which each node has up to four children. It has roughly
+// half the depth of the binary tree. Like FlatBVH, it may be shared across goroutines that use their own iterators.
+type BVH4[T math32.VolumeType[E], E math32.Number] struct {
+	nodes []wideNode[E]
+	vols  []T
+}
+
+// Collapse creates a BVH4 by merging each volume of the BVH with the largest of its children until it has four.
+func (b *BVol[T, E]) Collapse() *BVH4[T, E] {
+	wide := &BVH4[T, E]{}
+	if b.vol.IsNil() {
+		return wide
+	}
+	if b.depth == 0 {
+		// A single leaf still needs a node to hold its bounds.
+		wide.nodes = append(wide.nodes, wideNode[E]{})
+		wide.setChild(0, 0, b)
+		wide.nodes[0].count = 1
+		return wide
+	}
+	wide.collapse(b)
+	return wide
+}
+
+// collapse appends a node for b and its descendants, returning its index.
+func (w *BVH4[T, E]) collapse(b *BVol[T, E]) int32 {
+	children := [WIDTH]*BVol[T, E]{b.desc[0], b.desc[1]}
+	count := 2
+	for count < WIDTH {
+		// Replace the largest internal child with its children.
+		largest := -1
+		for i := 0; i < count; i++ {
+			if children[i].depth > 0 && (largest < 0 || children[i].vol.Score() > children[largest].vol.Score()) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			break
+		}
+		children[count] = children[largest].desc[1]
+		children[largest] = children[largest].desc[0]
+		count++
+	}
+
+	index := int32(len(w.nodes))
+	w.nodes = append(w.nodes, wideNode[E]{count: int32(count)})
+	for i := 0; i < count; i++ {
+		w.setChild(index, i, children[i])
+	}
+	return index
+}
+
+// setChild stores the bounds of child in the lane of the node and collapses it if it is internal.
+func (w *BVH4[T, E]) setChild(index int32, lane int, child *BVol[T, E]) {
+	point, delta := child.vol.GetPoint(), child.vol.GetDelta()
+	for d := 0; d < math32.DIMENSIONS; d++ {
+		w.nodes[index].min[d][lane] = point[d]
+		w.nodes[index].max[d][lane] = point[d] + delta[d]
+	}
+	if child.depth == 0 {
+		w.nodes[index].child[lane] = -int32(len(w.vols)) - 1
+		w.vols = append(w.vols, child.vol)
+	} else {
+		ref := w.collapse(child)
+		w.nodes[index].child[lane] = ref
+	}
+}
+
+// Len returns the number of volumes stored.
+func (w *BVH4[T, E]) Len() int {
+	return len(w.vols)
+}
+
+// Iterator for querying the BVH4. Iterators are not thread-safe; create one per goroutine.
+func (w *BVH4[T, E]) Iterator() *wideStack[T, E] {
+	stack := &wideStack[T, E]{wide: w}
+	stack.Reset()
+	return stack
+}
+
+// wideStack provides the query methods of orthStack for a BVH4. The stack holds references to nodes and leaves
+// whose bounds passed the test of their parent.
+type wideStack[T math32.VolumeType[E], E math32.Number] struct {
+	wide  *BVH4[T, E]
+	stack []int32
+	near  []wideNear[E]
+}
+
+// wideNear is a reference on the stack of Nearest, with the squared distance to its bounds.
+type wideNear[E math32.Number] struct {
+	ref  int32
+	dist E
+}
+
+// Reset the iterator to the root of the BVH4.
+func (s *wideStack[T, E]) Reset() {
+	s.stack = s.stack[:0]
+	if len(s.wide.nodes) > 0 {
+		s.stack = append(s.stack, 0)
+	}
+}
+
+// HasNext return true iff the tree has unvisited nodes.
+func (s *wideStack[T, E]) HasNext() bool {
+	return len(s.stack) > 0
+}
+
+// Query looks for intersections between the orth, o, and the BVH4 returning one intersection at a time.
+func (s *wideStack[T, E]) Query(o T) T {
+	point, delta := o.GetPoint(), o.GetDelta()
+	max := point.Add(delta)
+
+	for len(s.stack) > 0 {
+		ref := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		if ref < 0 {
+			if vol := s.wide.vols[-ref-1]; vol.Overlaps(o) {
+				return vol
+			}
+			continue
+		}
+
+		node := &s.wide.nodes[ref]
+		hit := [WIDTH]bool{true, true, true, true}
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			for lane := 0; lane < WIDTH; lane++ {
+				hit[lane] = hit[lane] && node.min[d][lane] <= max[d] && point[d] <= node.max[d][lane]
+			}
+		}
+		for lane := node.count - 1; lane >= 0; lane-- {
+			if hit[lane] {
+				s.stack = append(s.stack, node.child[lane])
+			}
+		}
+	}
+	var zero T
+	return zero
+}
+
+// Intersects traces the path of a moving orth through the BVH4 returning an orth and the distance from the source
+// orth's origin along it's delta. It does not guarantee order.
+func (s *wideStack[T, E]) Intersects(orth T, delta *math32.Coordinate[E]) (T, E) {
+	point, size := orth.GetPoint(), orth.GetDelta()
+	one, div := math32.Unit[E](), math32.DivFunc[E]()
+
+	for len(s.stack) > 0 {
+		ref := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		if ref < 0 {
+			vol := s.wide.vols[-ref-1]
+			if t := vol.Intersects(orth, delta); t >= 0 && t <= one {
+				return vol, t
+			}
+			continue
+		}
+
+		// Slab test for all lanes at once. See Orthotope.Intersects.
+		node := &s.wide.nodes[ref]
+		var inT [WIDTH]E
+		outT := [WIDTH]E{one, one, one, one}
+		for d := 0; d < math32.DIMENSIONS; d++ {
+			p0 := point[d]
+			p1 := size[d] + p0
+			for lane := 0; lane < WIDTH; lane++ {
+				if !math32.Finite(delta[d]) {
+					outT[lane] = -1
+				} else if math32.Abs(delta[d]) < math32.SmallestNormal[E]() {
+					if node.min[d][lane] > p1 || p0 > node.max[d][lane] {
+						outT[lane] = -1
+					}
+				} else {
+					var p0T, p1T E
+					if div != nil {
+						p0T, p1T = div(node.min[d][lane]-p1, delta[d]), div(node.max[d][lane]-p0, delta[d])
+					} else {
+						p0T, p1T = (node.min[d][lane]-p1)/delta[d], (node.max[d][lane]-p0)/delta[d]
+					}
+					if delta[d] < 0 {
+						p0T, p1T = p1T, p0T
+					}
+					inT[lane] = math32.Max(inT[lane], p0T)
+					outT[lane] = math32.Min(outT[lane], p1T)
+				}
+			}
+		}
+		for lane := node.count - 1; lane >= 0; lane-- {
+			if inT[lane] <= outT[lane] {
+				s.stack = append(s.stack, node.child[lane])
+			}
+		}
+	}
+	var zero T
+	return zero, -1
+}
+
+// Nearest returns the volume closest to point and the squared distance to its bounds, or -1 when the BVH4 is empty.
+// Like FlatBVH, it does not change the position of the iterator for Query or Intersects.
+func (s *wideStack[T, E]) Nearest(point math32.Coordinate[E]) (T, E) {
+	var best T
+	var bestDist E = -1
+	if len(s.wide.nodes) == 0 {
+		return best, bestDist
+	}
+
+	mul := math32.MulFunc[E]()
+	s.near = append(s.near[:0], wideNear[E]{})
+	for len(s.near) > 0 {
+		next := s.near[len(s.near)-1]
+		s.near = s.near[:len(s.near)-1]
+		if bestDist >= 0 && next.dist >= bestDist {
+			continue
+		}
+		if next.ref < 0 {
+			best, bestDist = s.wide.vols[-next.ref-1], next.dist
+			continue
+		}
+
+		node := &s.wide.nodes[next.ref]
+		var lanes [WIDTH]wideNear[E]
+		for lane := 0; lane < int(node.count); lane++ {
+			var min, max math32.Coordinate[E]
+			for d := 0; d < math32.DIMENSIONS; d++ {
+				min[d], max[d] = node.min[d][lane], node.max[d][lane]
+			}
+			lanes[lane] = wideNear[E]{ref: node.child[lane], dist: distanceSq(point, min, max, mul)}
+			// Visit the closest lane first by sorting the farthest to the bottom of the stack.
+			for i := lane; i > 0 && lanes[i].dist > lanes[i-1].dist; i-- {
+				lanes[i], lanes[i-1] = lanes[i-1], lanes[i]
+			}
+		}
+		for _, lane := range lanes[:node.count] {
+			if bestDist < 0 || lane.dist < bestDist {
+				s.near = append(s.near, lane)
+			}
+		}
+	}
+	return best, bestDist
+}
@@ -0,0 +1,130 @@
+package collision
+
+import (
+	"testing"
+
+	"github.com/briannoyama/bvh/math32"
+	. "github.com/briannoyama/bvh/math32"
+)
+
+func TestCollapse(t *testing.T) {
+	tree := getIdealTree()
+	wide := tree.Collapse()
+	if wide.Len() != len(leaf) {
+		t.Errorf("Expected %d volumes, got %d", len(leaf), wide.Len())
+	}
+	for _, node := range wide.nodes {
+		if node.count < 2 || int(node.count) > WIDTH {
+			t.Errorf("Unexpected number of children: %d", node.count)
+		}
+	}
+
+	single := &BVol[*math32.Orthotope[float32], float32]{}
+	single.Add(leaf[0])
+	iter := single.Collapse().Iterator()
+	if r := iter.Query(leaf[0]); r != leaf[0] {
+		t.Errorf("Querying a single volume returned %v", r)
+	}
+
+	empty := (&BVol[*math32.Orthotope[float32], float32]{}).Collapse()
+	if r := empty.Iterator().Query(leaf[0]); r != nil {
+		t.Errorf("Querying an empty hierarchy returned non nil value!\n")
+	}
+}
+
+func TestWideQuery(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := TopDownBVH[*Orthotope[int32], int32](orths)
+	iter := tree.Iterator()
+	wideIter := tree.Collapse().Iterator()
+
+	for _, q := range randomOrths(100) {
+		q.Delta = Coordinate[int32](q.Delta).Scale(5)
+		expected := map[*Orthotope[int32]]bool{}
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+			expected[r] = true
+		}
+
+		wideIter.Reset()
+		for r := wideIter.Query(q); r != nil; r = wideIter.Query(q) {
+			if !expected[r] {
+				t.Errorf("Querying %v returned unexpected value: %v\n", q.String(), r.String())
+			}
+			delete(expected, r)
+		}
+		for r := range expected {
+			t.Errorf("Querying %v did not return %v\n", q.String(), r.String())
+		}
+	}
+}
+
+func TestWideNearest(t *testing.T) {
+	orths := randomOrths(2000)
+	tree := TopDownBVH[*Orthotope[int32], int32](orths)
+	iter := tree.Iterator()
+	wideIter := tree.Collapse().Iterator()
+	for _, q := range randomOrths(100) {
+		expected, distance := iter.Nearest(q.Point)
+		if nearest, dist := wideIter.Nearest(q.Point); dist != distance ||
+			volDistanceSq(q.Point, nearest, nil) != distance {
+			t.Errorf("Nearest to %v was %v at %d, expected %v at %d", q.Point, nearest.String(), dist,
+				expected.String(), distance)
+		}
+	}
+
+	// The position of Query is kept.
+	q := &Orthotope[int32]{Point: Coordinate[int32]{0, 0, 0}, Delta: Coordinate[int32]{1000, 1000, 1000}}
+	wideIter.Reset()
+	wideIter.Query(q)
+	wideIter.Nearest(Coordinate[int32]{})
+	count := 1
+	for r := wideIter.Query(q); r != nil; r = wideIter.Query(q) {
+		count++
+	}
+	if count != len(orths) {
+		t.Errorf("Expected to query %d volumes around Nearest, got %d", len(orths), count)
+	}
+
+	if nearest, dist := (&BVH4[*Orthotope[int32], int32]{}).Iterator().Nearest(Coordinate[int32]{}); nearest != nil ||
+		dist != -1 {
+		t.Errorf("Expected nothing near an empty BVH4, got %v at %d", nearest, dist)
+	}
+}
+
+func TestWideIntersects(t *testing.T) {
+	tree := getIdealTree()
+	wide := tree.Collapse()
+	query, delta := sweepCases()
+
+	for in, q := range query {
+		expected := map[*Orthotope[float32]]float32{}
+		iter := tree.Iterator()
+		for r, d := iter.Intersects(q, delta[in]); r != nil; r, d = iter.Intersects(q, delta[in]) {
+			expected[r] = d
+		}
+		wideIter := wide.Iterator()
+		for r, d := wideIter.Intersects(q, delta[in]); r != nil; r, d = wideIter.Intersects(q, delta[in]) {
+			if e, ok := expected[r]; !ok || e != d {
+				t.Errorf("Intersection for %v returned unexpected value: %v at %v\n", q.String(), r.String(), d)
+			}
+			delete(expected, r)
+		}
+		for r := range expected {
+			t.Errorf("Intersection for %v did not return %v\n", q.String(), r.String())
+		}
+	}
+}
+
+func BenchmarkWideQuery(b *testing.B) {
+	orths := randomOrths(50000)
+	iter := TopDownBVH[*Orthotope[int32], int32](orths).Collapse().Iterator()
+	queries := randomOrths(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		iter.Reset()
+		for r := iter.Query(q); r != nil; r = iter.Query(q) {
+		}
+	}
+}
@@ -2,6 +2,8 @@
 // Copyright 2018 Brian Noyama. Subject to the the Apache License, Version 2.0.
 package discreet
 
+import "math"
+
 // SHIFT sets the number of bits for which Min and Max work.
 const SHIFT uint = 31
 
@@ -33,3 +35,22 @@ func Pow(n, p int32) int32 {
 	}
 	return result
 }
+
+// AddSat adds i and j, clamping to the range of an int32 instead of wrapping around
+func AddSat(i, j int32) int32 {
+	return clamp(int64(i) + int64(j))
+}
+
+// MulSat multiplies i and j, clamping to the range of an int32 instead of wrapping around
+func MulSat(i, j int32) int32 {
+	return clamp(int64(i) * int64(j))
+}
+
+func clamp(i int64) int32 {
+	if i > math.MaxInt32 {
+		return math.MaxInt32
+	} else if i < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(i)
+}
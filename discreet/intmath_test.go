@@ -2,6 +2,7 @@
 package discreet
 
 import (
+	"math"
 	"testing"
 )
 
@@ -48,3 +49,31 @@ func TestPow(t *testing.T) {
 		t.Errorf("Expected %d, got %d.", expected, actual)
 	}
 }
+
+func TestAddSat(t *testing.T) {
+	for _, c := range [][3]int32{
+		{3, 4, 7},
+		{math.MaxInt32, 1, math.MaxInt32},
+		{math.MaxInt32 - 5, math.MaxInt32 - 5, math.MaxInt32},
+		{math.MinInt32, -1, math.MinInt32},
+		{math.MinInt32, math.MaxInt32, -1},
+	} {
+		if actual := AddSat(c[0], c[1]); actual != c[2] {
+			t.Errorf("Expected %d + %d = %d, got %d.", c[0], c[1], c[2], actual)
+		}
+	}
+}
+
+func TestMulSat(t *testing.T) {
+	for _, c := range [][3]int32{
+		{-3, 4, -12},
+		{math.MaxInt32 / 2, 3, math.MaxInt32},
+		{1 << 16, 1 << 16, math.MaxInt32},
+		{math.MinInt32 / 2, 3, math.MinInt32},
+		{math.MaxInt32, 0, 0},
+	} {
+		if actual := MulSat(c[0], c[1]); actual != c[2] {
+			t.Errorf("Expected %d * %d = %d, got %d.", c[0], c[1], c[2], actual)
+		}
+	}
+}
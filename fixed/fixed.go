@@ -0,0 +1,173 @@
+// Package fixed provides a Q32.32 fixed point Number, with volumes for BVHs that give bit-identical results on every
+// platform, such as for lockstep networking. Unlike float32, the results do not depend on the compiler fusing
+// operations or on the rounding of the hardware.
+//
+// Add (or Insert) only adds, subtracts and compares coordinates, and the sweeps of BVol, FlatBVH and BVH4 divide with
+// Fixed.Div (see math32.DivFunc). The bulk builders (such as BinnedSAHBVH and LinearBVH) also build valid trees of
+// fixed volumes, but they estimate costs with floats, which may be fused differently by some compilers. Build with Add
+// where the shape of the tree must be identical on every platform.
+package fixed
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Fixed is a signed Q32.32 fixed point number: the value multiplied by 2^32. Add, subtract and compare Fixed values
+// with the builtin operators. Mul, Div and Sqrt round toward zero, and saturate rather than overflowing.
+type Fixed int64
+
+const (
+	// Shift is the number of fractional bits of a Fixed.
+	Shift = 32
+	// One is 1 as a Fixed.
+	One Fixed = 1 << Shift
+	// MaxFixed is the largest Fixed, whose value is just below 2^31; the raw int64 is math.MaxInt64.
+	MaxFixed Fixed = math.MaxInt64
+	// MinFixed is the smallest Fixed, whose value is -2^31; the raw int64 is math.MinInt64.
+	MinFixed Fixed = math.MinInt64
+)
+
+// FromInt returns i as a Fixed.
+func FromInt(i int32) Fixed {
+	return Fixed(i) << Shift
+}
+
+// FromFloat returns the Fixed nearest to f, saturating at MinFixed and MaxFixed.
+func FromFloat(f float64) Fixed {
+	scaled := math.Round(f * float64(One))
+	if scaled >= float64(MaxFixed) {
+		return MaxFixed
+	} else if scaled <= float64(MinFixed) {
+		return MinFixed
+	}
+	return Fixed(scaled)
+}
+
+// Float returns f as a float64, which is exact for values with up to 53 significant bits.
+func (f Fixed) Float() float64 {
+	return float64(f) / float64(One)
+}
+
+// Int returns the integer part of f, rounded toward negative infinity.
+func (f Fixed) Int() int32 {
+	return int32(f >> Shift)
+}
+
+// Mul returns f * g.
+func (f Fixed) Mul(g Fixed) Fixed {
+	hi, lo := bits.Mul64(abs(f), abs(g))
+	if hi>>(Shift-1) != 0 {
+		return saturate(f < 0 != (g < 0))
+	}
+	return signed(hi<<Shift|lo>>Shift, f < 0 != (g < 0))
+}
+
+// Div returns f / g. Division by zero saturates, except for 0 / 0, which is 0.
+func (f Fixed) Div(g Fixed) Fixed {
+	numerator, denominator := abs(f), abs(g)
+	hi, lo := numerator>>Shift, numerator<<Shift
+	if hi >= denominator {
+		if f == 0 {
+			return 0
+		}
+		return saturate(f < 0 != (g < 0))
+	}
+	quotient, _ := bits.Div64(hi, lo, denominator)
+	return signed(quotient, f < 0 != (g < 0))
+}
+
+// Sqrt returns the square root of f, rounded down, or 0 for negative values.
+func (f Fixed) Sqrt() Fixed {
+	if f <= 0 {
+		return 0
+	}
+	// The square root of the value times 2^64 is the root times 2^32.
+	return Fixed(sqrt128(uint64(f)>>Shift, uint64(f)<<Shift))
+}
+
+// Unit returns One, which math32.Unit uses as the end of a movement (see Orthotope.Intersects).
+func (Fixed) Unit() Fixed {
+	return One
+}
+
+// String returns f in decimal.
+func (f Fixed) String() string {
+	return strconv.FormatFloat(f.Float(), 'f', -1, 64)
+}
+
+// Distance returns the distance between a and b, rounded down. The squares are summed in 128 bits, so this is exact
+// for any coordinates within math32.MaxCoordinate.
+func Distance(a, b math32.Coordinate[Fixed]) Fixed {
+	// The squares have 64 fractional bits, so the root has 32.
+	if root := sqrt128(distanceSq128(a, b)); root <= uint64(MaxFixed) {
+		return Fixed(root)
+	}
+	return MaxFixed
+}
+
+// Dot returns the dot product of a and b.
+func Dot(a, b math32.Coordinate[Fixed]) Fixed {
+	var sum Fixed
+	for d := range a {
+		sum = math32.AddSat(sum, a[d].Mul(b[d]))
+	}
+	return sum
+}
+
+// distanceSq128 returns the squared distance between a and b as a 128 bit integer with 64 fractional bits.
+func distanceSq128(a, b math32.Coordinate[Fixed]) (hi, lo uint64) {
+	var carry uint64
+	for d := range a {
+		squareHi, squareLo := bits.Mul64(abs(a[d]-b[d]), abs(a[d]-b[d]))
+		lo, carry = bits.Add64(lo, squareLo, 0)
+		hi, _ = bits.Add64(hi, squareHi, carry)
+	}
+	return hi, lo
+}
+
+// sqrt128 returns the square root of the 128 bit integer hi, lo, rounded down. Each bit of the root is set from the
+// highest, when its square does not pass the integer.
+func sqrt128(hi, lo uint64) uint64 {
+	var root uint64
+	for bit := uint64(1) << 63; bit != 0; bit >>= 1 {
+		candidate := root | bit
+		squareHi, squareLo := bits.Mul64(candidate, candidate)
+		if squareHi < hi || (squareHi == hi && squareLo <= lo) {
+			root = candidate
+		}
+	}
+	return root
+}
+
+// abs returns the magnitude of f, which is also correct for MinFixed.
+func abs(f Fixed) uint64 {
+	if f < 0 {
+		return uint64(-f)
+	}
+	return uint64(f)
+}
+
+// signed returns the magnitude as a Fixed, saturating at MinFixed or MaxFixed.
+func signed(magnitude uint64, negative bool) Fixed {
+	if negative {
+		if magnitude > uint64(MaxFixed)+1 {
+			return MinFixed
+		}
+		return Fixed(-magnitude)
+	}
+	if magnitude > uint64(MaxFixed) {
+		return MaxFixed
+	}
+	return Fixed(magnitude)
+}
+
+func saturate(negative bool) Fixed {
+	if negative {
+		return MinFixed
+	}
+	return MaxFixed
+}
@@ -0,0 +1,94 @@
+package fixed
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// randomFixed returns values of many magnitudes, including some that overflow when multiplied.
+func randomFixed(r *rand.Rand) Fixed {
+	return Fixed(r.Int63() >> r.Intn(63) * int64(1-2*r.Intn(2)))
+}
+
+// reference returns the result of an exact calculation, saturated like Fixed.
+func reference(value *big.Int) Fixed {
+	if value.Cmp(big.NewInt(math.MaxInt64)) > 0 {
+		return MaxFixed
+	} else if value.Cmp(big.NewInt(math.MinInt64)) < 0 {
+		return MinFixed
+	}
+	return Fixed(value.Int64())
+}
+
+func TestArithmetic(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	one := big.NewInt(int64(One))
+	for i := 0; i < 100000; i++ {
+		f, g := randomFixed(r), randomFixed(r)
+		bigF, bigG := big.NewInt(int64(f)), big.NewInt(int64(g))
+
+		product := new(big.Int).Mul(bigF, bigG)
+		if expected := reference(product.Quo(product, one)); f.Mul(g) != expected {
+			t.Fatalf("Expected %d * %d = %d, got %d", f, g, expected, f.Mul(g))
+		}
+		if g != 0 {
+			quotient := new(big.Int).Mul(bigF, one)
+			if expected := reference(quotient.Quo(quotient, bigG)); f.Div(g) != expected {
+				t.Fatalf("Expected %d / %d = %d, got %d", f, g, expected, f.Div(g))
+			}
+		}
+		if f > 0 {
+			root := new(big.Int).Mul(bigF, one)
+			if expected := reference(root.Sqrt(root)); f.Sqrt() != expected {
+				t.Fatalf("Expected sqrt(%d) = %d, got %d", f, expected, f.Sqrt())
+			}
+		}
+	}
+
+	half, two := One/2, FromInt(2)
+	cases := [][3]Fixed{
+		{half.Mul(two), One, 0},
+		{FromInt(-3).Div(two), FromFloat(-1.5), 0},
+		{FromInt(9).Sqrt(), FromInt(3), 0},
+		{MaxFixed.Mul(two), MaxFixed, 0},
+		{MinFixed.Mul(two), MinFixed, 0},
+		{One.Div(0), MaxFixed, 0},
+		{Fixed(0).Div(0), 0, 0},
+		{FromInt(-4).Sqrt(), 0, 0},
+	}
+	for i, c := range cases {
+		if c[0] != c[1] {
+			t.Errorf("Case %d: expected %v, got %v", i, c[1], c[0])
+		}
+	}
+	if s := FromFloat(-2.25).String(); s != "-2.25" || FromFloat(-2.25).Int() != -3 {
+		t.Errorf("Unexpected conversion of -2.25: %s, %d", s, FromFloat(-2.25).Int())
+	}
+}
+
+func TestDistance(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 10000; i++ {
+		var a, b Coordinate
+		sum := new(big.Int)
+		for d := range a {
+			// Coordinates within math32.MaxCoordinate.
+			a[d], b[d] = randomFixed(r)/2, randomFixed(r)/2
+			diff := big.NewInt(int64(a[d] - b[d]))
+			sum.Add(sum, diff.Mul(diff, diff))
+		}
+		if expected := reference(sum.Sqrt(sum)); Distance(a, b) != expected {
+			t.Fatalf("Expected the distance from %v to %v to be %d, got %d", a, b, expected, Distance(a, b))
+		}
+	}
+
+	a, b := Coordinate{FromInt(1), FromInt(2), FromInt(3)}, Coordinate{FromInt(4), FromInt(6), FromInt(3)}
+	if distance := Distance(a, b); distance != FromInt(5) {
+		t.Errorf("Expected 5, got %v", distance)
+	}
+	if dot := Dot(a, b); dot != FromInt(4+12+9) {
+		t.Errorf("Expected 25, got %v", dot)
+	}
+}
@@ -0,0 +1,153 @@
+package fixed
+
+import (
+	"fmt"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Coordinate is a point or vector of Fixed values.
+type Coordinate = math32.Coordinate[Fixed]
+
+// Orthotope is an axis aligned box defined by a point (location) and a delta (width, height, etc), like
+// math32.Orthotope, but whose sweeps (see Intersects) divide with Fixed.Div.
+type Orthotope struct {
+	Point Coordinate
+	Delta Coordinate
+}
+
+// NewOrthotope returns an orthotope at point with size delta, or an error from math32.Validate.
+func NewOrthotope(point, delta Coordinate) (*Orthotope, error) {
+	o := &Orthotope{Point: point, Delta: delta}
+	if err := math32.Validate[Fixed](o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (o *Orthotope) GetPoint() Coordinate {
+	return o.Point
+}
+
+func (o *Orthotope) GetDelta() Coordinate {
+	return o.Delta
+}
+
+func (o *Orthotope) New() math32.VolumeType[Fixed] {
+	return &Orthotope{}
+}
+
+// CopyFrom sets o to the bounds of src.
+func (o *Orthotope) CopyFrom(src math32.VolumeType[Fixed]) {
+	o.Point, o.Delta = src.GetPoint(), src.GetDelta()
+}
+
+// Overlaps returns true if the bounds of other (see GetPoint and GetDelta) intersect o.
+func (o *Orthotope) Overlaps(other math32.VolumeType[Fixed]) bool {
+	otherPoint, otherDelta := other.GetPoint(), other.GetDelta()
+	for d, p0 := range otherPoint {
+		if o.Point[d] > p0+otherDelta[d] || p0 > o.Point[d]+o.Delta[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains returns true if the bounds of other are within o.
+func (o *Orthotope) Contains(other math32.VolumeType[Fixed]) bool {
+	otherPoint, otherDelta := other.GetPoint(), other.GetDelta()
+	for d, p0 := range o.Point {
+		if otherPoint[d] < p0 || otherPoint[d]+otherDelta[d] > p0+o.Delta[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects returns 0 <= t <= One for where other first touches o when moved along delta, else 2 * One.
+func (o *Orthotope) Intersects(other math32.VolumeType[Fixed], delta *Coordinate) Fixed {
+	otherPoint, otherDelta := other.GetPoint(), other.GetDelta()
+	inT, outT := Fixed(0), One
+	for d, p0 := range otherPoint {
+		p1 := otherDelta[d] + p0
+		if delta[d] == 0 {
+			// Parallel to the slab, which other is either within or not.
+			if o.Point[d] > p1 || p0 > o.Point[d]+o.Delta[d] {
+				return 2 * One
+			}
+			continue
+		}
+
+		p0T := (o.Point[d] - p1).Div(delta[d])
+		p1T := (o.Point[d] + o.Delta[d] - p0).Div(delta[d])
+		if delta[d] < 0 {
+			// Swap p0 and p1 for negative directions.
+			p0T, p1T = p1T, p0T
+		}
+		inT, outT = max(inT, p0T), min(outT, p1T)
+		if inT > outT {
+			return 2 * One
+		}
+	}
+	return inT
+}
+
+// MinBounds sets o to the smallest orthotope containing the bounds of the volumes.
+func (o *Orthotope) MinBounds(volumes ...math32.VolumeType[Fixed]) {
+	if len(volumes) == 0 {
+		return
+	}
+	low, high := volumes[0].GetPoint(), volumes[0].GetPoint().Add(volumes[0].GetDelta())
+	for _, v := range volumes[1:] {
+		point, delta := v.GetPoint(), v.GetDelta()
+		for d := range low {
+			low[d], high[d] = min(low[d], point[d]), max(high[d], point[d]+delta[d])
+		}
+	}
+	o.Point, o.Delta = low, high.Sub(low)
+}
+
+// MinBoundsPair is equivalent to MinBounds(first, second), but does not allocate.
+func (o *Orthotope) MinBoundsPair(first, second math32.VolumeType[Fixed]) {
+	firstPoint, firstDelta := first.GetPoint(), first.GetDelta()
+	secondPoint, secondDelta := second.GetPoint(), second.GetDelta()
+	for d := range o.Point {
+		low := min(firstPoint[d], secondPoint[d])
+		o.Delta[d] = max(firstPoint[d]+firstDelta[d], secondPoint[d]+secondDelta[d]) - low
+		o.Point[d] = low
+	}
+}
+
+// Score adds the lengths of the sides, saturating at MaxFixed.
+func (o *Orthotope) Score() Fixed {
+	var score Fixed
+	for _, d := range o.Delta {
+		score = math32.AddSat(score, d)
+	}
+	return score
+}
+
+// Equals returns true if other is an Orthotope with the same point and delta.
+func (o *Orthotope) Equals(other math32.VolumeType[Fixed]) bool {
+	if o == nil || other == nil || other.IsNil() {
+		return o == nil && (other == nil || other.IsNil())
+	}
+	otherOrth, ok := other.(*Orthotope)
+	return ok && o.Point == otherOrth.Point && o.Delta == otherOrth.Delta
+}
+
+func (o *Orthotope) IsNil() bool {
+	return o == nil
+}
+
+func (o *Orthotope) IsSame(other math32.VolumeType[Fixed]) bool {
+	if other == nil || other.IsNil() {
+		return o == nil
+	}
+	otherOrth, ok := other.(*Orthotope)
+	return ok && o == otherOrth
+}
+
+func (o *Orthotope) String() string {
+	return fmt.Sprintf("Point %v, Delta %v", o.Point, o.Delta)
+}
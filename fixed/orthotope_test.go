@@ -0,0 +1,269 @@
+package fixed
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	collision "github.com/briannoyama/bvh/bvh"
+	"github.com/briannoyama/bvh/math32"
+)
+
+func orth(x, y, z, dx, dy, dz float64) *Orthotope {
+	return &Orthotope{
+		Point: Coordinate{FromFloat(x), FromFloat(y), FromFloat(z)},
+		Delta: Coordinate{FromFloat(dx), FromFloat(dy), FromFloat(dz)},
+	}
+}
+
+func TestOrthotope(t *testing.T) {
+	o1, o2, o3 := orth(10, -20, 0, 30, 30, 1), orth(15, -20, 0, 20, 20, 1), orth(-10, 25, 0, 30, 30, 1)
+	if !o1.Overlaps(o2) || o1.Overlaps(o3) || !o1.Contains(o2) || o2.Contains(o1) {
+		t.Errorf("Unexpected overlaps among %v, %v and %v", o1, o2, o3)
+	}
+
+	bounds, pair := &Orthotope{}, &Orthotope{}
+	bounds.MinBounds(o1, o2, o3)
+	pair.MinBoundsPair(o3, o1)
+	if expected := orth(-10, -20, 0, 50, 75, 1); !bounds.Equals(expected) || !pair.Equals(expected) {
+		t.Errorf("Expected bounds of %v, got %v and %v", expected, bounds, pair)
+	}
+	if score := bounds.Score(); score != FromInt(126) {
+		t.Errorf("Expected a score of 126, got %v", score)
+	}
+
+	// The sweeps of math32.TestIntersects.
+	o := orth(-10, 0, 0, 10, 10, 1)
+	delta := Coordinate{FromInt(20), FromInt(-20)}
+	for _, c := range []struct {
+		wall     *Orthotope
+		expected Fixed
+	}{
+		{orth(-10, -25, 0, 10, 10, 1), 2 * One},
+		{orth(15, -25, 0, 10, 10, 1), FromFloat(0.75)},
+		{orth(10, -5, 0, 10, 10, 1), FromFloat(0.5)},
+		{orth(10, -5, 5, 10, 10, 1), 2 * One},
+	} {
+		if actual := c.wall.Intersects(o, &delta); actual != c.expected {
+			t.Errorf("Expected %v for %v, got %v", c.expected, c.wall, actual)
+		}
+	}
+
+	if _, err := NewOrthotope(Coordinate{MaxFixed}, Coordinate{}); err == nil {
+		t.Errorf("Expected an error for an orthotope beyond math32.MaxCoordinate")
+	}
+}
+
+// hashTree hashes the shape of the BVH: the depth and bounds of each volume in pre-order.
+func hashTree[T math32.VolumeType[Fixed]](h hash.Hash64, iter interface {
+	Walk(func(T, int32) bool, func(T) bool)
+}) {
+	iter.Walk(func(bounds T, depth int32) bool {
+		binary.Write(h, binary.LittleEndian, depth)
+		binary.Write(h, binary.LittleEndian, bounds.GetPoint())
+		binary.Write(h, binary.LittleEndian, bounds.GetDelta())
+		return true
+	}, func(T) bool { return true })
+}
+
+func randomOrths(r *rand.Rand, n int) []*Orthotope {
+	orths := make([]*Orthotope, n)
+	for i := range orths {
+		orths[i] = &Orthotope{}
+		for d := range orths[i].Point {
+			// Fractions of a unit in a range of 1000.
+			orths[i].Point[d] = Fixed(r.Int63n(int64(FromInt(1000))))
+			orths[i].Delta[d] = Fixed(r.Int63n(int64(FromInt(20))))
+		}
+	}
+	return orths
+}
+
+// goldenOrthotopeHash is the hash of the BVH and queries in TestOrthotopeBVH, which every platform must reproduce.
+const goldenOrthotopeHash uint64 = 0xc5b3bab4f54a3e55
+
+func TestOrthotopeBVH(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	orths := randomOrths(r, 1000)
+	tree := &collision.BVol[*Orthotope, Fixed]{}
+	iter := tree.Iterator()
+	for _, o := range orths {
+		if err := iter.Insert(o); err != nil {
+			t.Fatalf("Unable to insert %v: %v", o, err)
+		}
+	}
+	for _, o := range orths[:300] {
+		if err := iter.Delete(o); err != nil {
+			t.Fatalf("Unable to delete %v: %v", o, err)
+		}
+	}
+
+	h := fnv.New64a()
+	hashTree[*Orthotope](h, iter)
+	remaining := orths[300:]
+	delta := Coordinate{FromInt(-50), FromFloat(20.5), FromFloat(0.125)}
+	for _, q := range randomOrths(r, 100) {
+		q.Delta = q.Delta.Scale(5)
+		overlapping, hits, closest := 0, 0, MaxFixed
+		for _, o := range remaining {
+			if o.Overlaps(q) {
+				overlapping++
+			}
+			if o.Intersects(q, &delta) <= One {
+				hits++
+			}
+			closest = min(closest, distanceSq(q.Point, o))
+		}
+
+		iter.Reset()
+		for found := iter.Query(q); !found.IsNil(); found = iter.Query(q) {
+			binary.Write(h, binary.LittleEndian, found.Point)
+			overlapping--
+		}
+		nearest, distance := iter.Nearest(q.Point)
+		binary.Write(h, binary.LittleEndian, nearest.Point)
+		binary.Write(h, binary.LittleEndian, distance)
+		iter.Reset()
+		for hit, at := iter.Intersects(q, &delta); !hit.IsNil(); hit, at = iter.Intersects(q, &delta) {
+			binary.Write(h, binary.LittleEndian, hit.Point)
+			binary.Write(h, binary.LittleEndian, at)
+			hits--
+		}
+		if overlapping != 0 || hits != 0 || distance != closest {
+			t.Errorf("Unexpected results for %v: %d, %d and %v (expected %v)", q, overlapping, hits, distance, closest)
+		}
+	}
+	if h.Sum64() != goldenOrthotopeHash {
+		t.Errorf("Expected a hash of %#x, got %#x", goldenOrthotopeHash, h.Sum64())
+	}
+}
+
+// fixedIterator is implemented by the iterators of BVol, FlatBVH and BVH4.
+type fixedIterator interface {
+	Reset()
+	Query(*Orthotope) *Orthotope
+	Intersects(*Orthotope, *Coordinate) (*Orthotope, Fixed)
+}
+
+func TestOrthotopeBuilders(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	orths := randomOrths(r, 1000)
+	queries := randomOrths(r, 50)
+	for _, q := range queries {
+		q.Delta = q.Delta.Scale(5)
+	}
+	delta := Coordinate{FromInt(-50), FromFloat(20.5), FromFloat(0.125)}
+	builders := map[string]func([]*Orthotope) *collision.BVol[*Orthotope, Fixed]{
+		"TopDownBVH":   collision.TopDownBVH[*Orthotope, Fixed],
+		"LinearBVH":    collision.LinearBVH[*Orthotope, Fixed],
+		"BinnedSAHBVH": func(o []*Orthotope) *collision.BVol[*Orthotope, Fixed] { return collision.BinnedSAHBVH(o, 4) },
+	}
+	for name, build := range builders {
+		tree := build(append([]*Orthotope(nil), orths...))
+		iters := map[string]fixedIterator{
+			"BVol": tree.Iterator(), "FlatBVH": tree.Freeze().Iterator(), "BVH4": tree.Collapse().Iterator(),
+		}
+		for kind, iter := range iters {
+			for _, q := range queries {
+				overlapping, hits := map[*Orthotope]bool{}, map[*Orthotope]Fixed{}
+				for _, o := range orths {
+					if o.Overlaps(q) {
+						overlapping[o] = true
+					}
+					if at := o.Intersects(q, &delta); at <= One {
+						hits[o] = at
+					}
+				}
+
+				iter.Reset()
+				for found := iter.Query(q); !found.IsNil(); found = iter.Query(q) {
+					if !overlapping[found] {
+						t.Errorf("%s %s: querying %v returned unexpected volume %v", name, kind, q, found)
+					}
+					delete(overlapping, found)
+				}
+				iter.Reset()
+				for hit, at := iter.Intersects(q, &delta); !hit.IsNil(); hit, at = iter.Intersects(q, &delta) {
+					if expected, ok := hits[hit]; !ok || expected != at {
+						t.Errorf("%s %s: tracing %v returned unexpected volume %v at %v", name, kind, q, hit, at)
+					}
+					delete(hits, hit)
+				}
+				if len(overlapping) > 0 || len(hits) > 0 {
+					t.Errorf("%s %s: missed %d overlapping and %d intersecting volumes for %v", name, kind,
+						len(overlapping), len(hits), q)
+				}
+			}
+		}
+	}
+}
+
+func TestOrthotopeMapped(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	orths := randomOrths(r, 500)
+	tree := &collision.BVol[*Orthotope, Fixed]{}
+	ids := map[*Orthotope]uint64{}
+	for index, o := range orths {
+		tree.Add(o)
+		ids[o] = uint64(index)
+	}
+	var buf bytes.Buffer
+	if err := tree.Freeze().Write(&buf, func(o *Orthotope) uint64 { return ids[o] }); err != nil {
+		t.Fatalf("Unable to write: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tree.bvh")
+	os.WriteFile(path, buf.Bytes(), 0o600)
+	if _, err := collision.OpenMapped[int64](path, true); !errors.Is(err, collision.ErrFormat) {
+		t.Errorf("Expected ErrFormat opening fixed point values as int64, got %v", err)
+	}
+	mapped, err := collision.OpenMapped[Fixed](path, true)
+	if err != nil {
+		t.Fatalf("Unable to open: %v", err)
+	}
+	defer mapped.Close()
+
+	iter := mapped.Iterator()
+	delta := Coordinate{FromInt(-50), FromFloat(20.5), FromFloat(0.125)}
+	for _, q := range randomOrths(r, 100) {
+		expected := map[uint64]Fixed{}
+		for index, o := range orths {
+			if at := o.Intersects(q, &delta); at <= One {
+				expected[uint64(index)] = at
+			}
+		}
+		iter.Reset()
+		for id, at, ok := iter.Intersects(q, &delta); ok; id, at, ok = iter.Intersects(q, &delta) {
+			if e, found := expected[id]; !found || e != at {
+				t.Errorf("Tracing %v returned unexpected volume %d at %v", q, id, at)
+			}
+			delete(expected, id)
+		}
+		if len(expected) > 0 {
+			t.Errorf("Tracing %v did not return %v", q, expected)
+		}
+	}
+
+	// Moving away from every volume hits nothing.
+	away := orth(2000, 2000, 2000, 1, 1, 1)
+	iter.Reset()
+	if id, at, ok := iter.Intersects(away, &Coordinate{One, One, One}); ok {
+		t.Errorf("Moving away hit volume %d at %v", id, at)
+	}
+}
+
+// distanceSq returns the squared distance from point to o, as calculated by the BVH.
+func distanceSq(point Coordinate, o *Orthotope) Fixed {
+	var sum Fixed
+	for d, p := range point {
+		if diff := max(o.Point[d]-p, p-o.Point[d]-o.Delta[d], 0); diff > 0 {
+			sum += diff.Mul(diff)
+		}
+	}
+	return sum
+}
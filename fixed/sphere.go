@@ -0,0 +1,197 @@
+package fixed
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Sphere is defined by a center and radius, like math32.Sphere, but measured with the exact Distance.
+type Sphere struct {
+	Center Coordinate
+	Radius Fixed
+}
+
+// NewSphere returns a sphere at center with radius, or an error from math32.Validate.
+func NewSphere(center Coordinate, radius Fixed) (*Sphere, error) {
+	s := &Sphere{Center: center, Radius: radius}
+	if err := math32.Validate[Fixed](s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Sphere) GetCenter() Coordinate {
+	return s.Center
+}
+
+func (s *Sphere) GetRadius() Fixed {
+	return s.Radius
+}
+
+// GetPoint returns the lowest corner of the bounds of s.
+func (s *Sphere) GetPoint() Coordinate {
+	return s.Center.Sub(math32.FillCoordinate(s.Radius))
+}
+
+// GetDelta returns the size of the bounds of s, saturating at MaxFixed.
+func (s *Sphere) GetDelta() Coordinate {
+	return math32.FillCoordinate(math32.AddSat(s.Radius, s.Radius))
+}
+
+func (s *Sphere) New() math32.VolumeType[Fixed] {
+	return &Sphere{}
+}
+
+// CopyFrom sets s to a copy of src, if it is a sphere.
+func (s *Sphere) CopyFrom(src math32.VolumeType[Fixed]) {
+	if other, ok := src.(*Sphere); ok {
+		*s = *other
+	}
+}
+
+// Overlaps returns true if other is a Sphere that touches s. The comparison is exact.
+func (s *Sphere) Overlaps(other math32.VolumeType[Fixed]) bool {
+	otherSphere, ok := other.(*Sphere)
+	return ok && within(s.Center, otherSphere.Center, s.Radius+otherSphere.Radius)
+}
+
+// Contains returns true if other is a Sphere within s.
+func (s *Sphere) Contains(other math32.VolumeType[Fixed]) bool {
+	otherSphere, ok := other.(*Sphere)
+	return ok && Distance(s.Center, otherSphere.Center)+otherSphere.Radius <= s.Radius
+}
+
+// Intersects returns 0 <= t <= One for where other (a Sphere) first touches s when moved along delta, else 2 * One.
+// Squares of lengths are Fixed, so sweeps are only accurate for lengths up to about 46000.
+func (s *Sphere) Intersects(other math32.VolumeType[Fixed], delta *Coordinate) Fixed {
+	otherSphere, ok := other.(*Sphere)
+	if !ok {
+		return 2 * One
+	}
+	reach := math32.AddSat(s.Radius, otherSphere.Radius)
+	if within(s.Center, otherSphere.Center, reach) {
+		return 0
+	}
+	length := Distance(*delta, Coordinate{})
+	if length == 0 {
+		return 2 * One
+	}
+
+	// Project the offset to s onto the direction of movement, to find the closest approach.
+	offset := s.Center.Sub(otherSphere.Center)
+	along := Dot(offset, *delta).Div(length)
+	gap := math32.SubSat(reach.Mul(reach), math32.SubSat(Dot(offset, offset), along.Mul(along)))
+	if along < 0 || gap < 0 {
+		return 2 * One
+	}
+	if t := max(along-gap.Sqrt(), 0).Div(length); t <= One {
+		return t
+	}
+	return 2 * One
+}
+
+// MinBounds sets s to a sphere containing the spheres among volumes. Each sphere not yet contained grows the bounds
+// to just include it, so the result is deterministic but not always the smallest.
+func (s *Sphere) MinBounds(volumes ...math32.VolumeType[Fixed]) {
+	var spheres []*Sphere
+	for _, v := range volumes {
+		if sphere, ok := v.(*Sphere); ok {
+			spheres = append(spheres, sphere)
+		}
+	}
+	if len(spheres) == 0 {
+		return
+	}
+
+	bounds := *spheres[0]
+	for _, sphere := range spheres[1:] {
+		bounds.include(sphere)
+	}
+	for _, sphere := range spheres {
+		bounds.pad(sphere)
+	}
+	*s = bounds
+}
+
+// MinBoundsPair is equivalent to MinBounds(first, second), but does not allocate.
+func (s *Sphere) MinBoundsPair(first, second math32.VolumeType[Fixed]) {
+	a, aOk := first.(*Sphere)
+	b, bOk := second.(*Sphere)
+	if !aOk {
+		a, aOk = b, bOk
+	} else if !bOk {
+		b = a
+	}
+	if !aOk {
+		return
+	}
+	bounds := *a
+	bounds.include(b)
+	bounds.pad(a)
+	bounds.pad(b)
+	*s = bounds
+}
+
+// include grows s to just include sphere, moving the center toward it by how much the radius grows.
+func (s *Sphere) include(sphere *Sphere) {
+	distance := Distance(s.Center, sphere.Center)
+	if distance+sphere.Radius <= s.Radius {
+		return
+	} else if distance+s.Radius <= sphere.Radius {
+		*s = *sphere
+		return
+	}
+	radius := (distance + s.Radius + sphere.Radius) >> 1
+	scale := (radius - s.Radius).Div(distance)
+	for d, c := range sphere.Center {
+		s.Center[d] += (c - s.Center[d]).Mul(scale)
+	}
+	s.Radius = radius
+}
+
+// pad the radius of s to contain sphere despite Distance rounding down.
+func (s *Sphere) pad(sphere *Sphere) {
+	s.Radius = max(s.Radius, Distance(s.Center, sphere.Center)+sphere.Radius+1)
+}
+
+// Score is the diameter, saturating at MaxFixed.
+func (s *Sphere) Score() Fixed {
+	return math32.AddSat(s.Radius, s.Radius)
+}
+
+// Equals returns true if other is a Sphere with the same center and radius.
+func (s *Sphere) Equals(other math32.VolumeType[Fixed]) bool {
+	if s == nil || other == nil || other.IsNil() {
+		return s == nil && (other == nil || other.IsNil())
+	}
+	otherSphere, ok := other.(*Sphere)
+	return ok && s.Center == otherSphere.Center && s.Radius == otherSphere.Radius
+}
+
+func (s *Sphere) IsNil() bool {
+	return s == nil
+}
+
+func (s *Sphere) IsSame(other math32.VolumeType[Fixed]) bool {
+	if other == nil || other.IsNil() {
+		return s == nil
+	}
+	otherSphere, ok := other.(*Sphere)
+	return ok && s == otherSphere
+}
+
+func (s *Sphere) String() string {
+	return fmt.Sprintf("Center %v, Radius %v", s.Center, s.Radius)
+}
+
+// within returns true iff a and b are no farther apart than reach, comparing the squares exactly in 128 bits.
+func within(a, b Coordinate, reach Fixed) bool {
+	if reach < 0 {
+		return false
+	}
+	hi, lo := distanceSq128(a, b)
+	reachHi, reachLo := bits.Mul64(uint64(reach), uint64(reach))
+	return hi < reachHi || (hi == reachHi && lo <= reachLo)
+}
@@ -0,0 +1,151 @@
+package fixed
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+	"testing"
+
+	collision "github.com/briannoyama/bvh/bvh"
+	"github.com/briannoyama/bvh/math32"
+)
+
+func sphere(x, y, z, r float64) *Sphere {
+	return &Sphere{Center: Coordinate{FromFloat(x), FromFloat(y), FromFloat(z)}, Radius: FromFloat(r)}
+}
+
+func TestSphere(t *testing.T) {
+	s1, s2, s3 := sphere(0, 0, 0, 5), sphere(3, 4, 0, 3), sphere(10, 0, 0, 2)
+	touching := sphere(0, 0, 8, 3)
+	if !s1.Overlaps(s2) || s1.Overlaps(s3) || !s1.Overlaps(touching) || s1.Contains(s2) ||
+		!s1.Contains(sphere(1, 1, 1, 2)) {
+		t.Errorf("Unexpected overlaps among %v, %v, %v and %v", s1, s2, s3, touching)
+	}
+
+	cases := []struct {
+		other    *Sphere
+		delta    Coordinate
+		expected Fixed
+	}{
+		{s3, Coordinate{FromInt(-6)}, FromFloat(0.5)},
+		{s3, Coordinate{FromInt(-2)}, 2 * One},
+		{s3, Coordinate{FromInt(6)}, 2 * One},
+		{s3, Coordinate{}, 2 * One},
+		{s2, Coordinate{}, 0},
+		// Passing 7 from the center, with a reach of 7.
+		{sphere(-20, 7, 0, 2), Coordinate{FromInt(40)}, FromFloat(0.5)},
+		{sphere(-20, 7.25, 0, 2), Coordinate{FromInt(40)}, 2 * One},
+	}
+	for _, c := range cases {
+		if actual := s1.Intersects(c.other, &c.delta); actual != c.expected {
+			t.Errorf("Expected %v moving %v by %v, got %v", c.expected, c.other, c.delta, actual)
+		}
+	}
+
+	huge := &Sphere{Radius: MaxFixed - One}
+	if delta := huge.GetDelta(); delta != math32.FillCoordinate(MaxFixed) {
+		t.Errorf("Expected the delta of %v to saturate, got %v", huge, delta)
+	}
+	if actual := huge.Intersects(huge, &Coordinate{One}); actual != 0 {
+		t.Errorf("Expected %v to already touch itself, got %v", huge, actual)
+	}
+
+	r := rand.New(rand.NewSource(3))
+	bounds := &Sphere{}
+	for i := 0; i < 1000; i++ {
+		spheres := randomSpheres(r, 1+r.Intn(5))
+		volumes := make([]math32.VolumeType[Fixed], len(spheres))
+		for v, s := range spheres {
+			volumes[v] = s
+		}
+		bounds.MinBounds(volumes...)
+		for _, s := range spheres {
+			if !bounds.Contains(s) {
+				t.Fatalf("Bounds %v do not contain %v", bounds, s)
+			}
+		}
+		if pair := (&Sphere{}); len(spheres) == 2 {
+			if pair.MinBoundsPair(spheres[0], spheres[1]); !pair.Equals(bounds) {
+				t.Fatalf("Expected the bounds %v of a pair, got %v", bounds, pair)
+			}
+		}
+	}
+	s1, s2 = randomSpheres(r, 1)[0], randomSpheres(r, 1)[0]
+	if allocs := testing.AllocsPerRun(10, func() { bounds.MinBoundsPair(s1, s2) }); allocs != 0 {
+		t.Errorf("Expected no allocations, got %v", allocs)
+	}
+}
+
+func randomSpheres(r *rand.Rand, n int) []*Sphere {
+	spheres := make([]*Sphere, n)
+	for i := range spheres {
+		spheres[i] = &Sphere{Radius: Fixed(r.Int63n(int64(FromInt(10))))}
+		for d := range spheres[i].Center {
+			spheres[i].Center[d] = Fixed(r.Int63n(int64(FromInt(1000))))
+		}
+	}
+	return spheres
+}
+
+// goldenSphereHash is the hash of the BVH and queries in TestSphereBVH, which every platform must reproduce.
+const goldenSphereHash uint64 = 0xfd1fe98730d34bf1
+
+func TestSphereBVH(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	spheres := randomSpheres(r, 1000)
+	tree := &collision.BVol[*Sphere, Fixed]{}
+	iter := tree.Iterator()
+	for _, s := range spheres {
+		if err := iter.Insert(s); err != nil {
+			t.Fatalf("Unable to insert %v: %v", s, err)
+		}
+	}
+	for _, s := range spheres[:300] {
+		if err := iter.Delete(s); err != nil {
+			t.Fatalf("Unable to delete %v: %v", s, err)
+		}
+	}
+
+	h := fnv.New64a()
+	hashTree[*Sphere](h, iter)
+	remaining := spheres[300:]
+	delta := Coordinate{FromInt(30), FromFloat(-20.5), FromFloat(0.375)}
+	for _, q := range randomSpheres(r, 100) {
+		q.Radius *= 3
+		overlapping, hits, near := 0, 0, 0
+		for _, s := range remaining {
+			if s.Overlaps(q) {
+				overlapping++
+			}
+			if s.Intersects(q, &delta) <= One {
+				hits++
+			}
+			if within(s.Center, q.Center, s.Radius+q.Radius) {
+				near++
+			}
+		}
+
+		iter.Reset()
+		for found := iter.Query(q); !found.IsNil(); found = iter.Query(q) {
+			binary.Write(h, binary.LittleEndian, found.Center)
+			overlapping--
+		}
+		iter.Reset()
+		for hit, at := iter.Intersects(q, &delta); !hit.IsNil(); hit, at = iter.Intersects(q, &delta) {
+			binary.Write(h, binary.LittleEndian, hit.Center)
+			binary.Write(h, binary.LittleEndian, at)
+			hits--
+		}
+		iter.WithinRadius(q.Center, q.Radius, func(s *Sphere) bool {
+			binary.Write(h, binary.LittleEndian, s.Center)
+			near--
+			return true
+		})
+		if overlapping != 0 || hits != 0 || near != 0 {
+			t.Errorf("Unexpected results for %v: %d, %d and %d", q, overlapping, hits, near)
+		}
+	}
+	if h.Sum64() != goldenSphereHash {
+		t.Errorf("Expected a hash of %#x, got %#x", goldenSphereHash, h.Sum64())
+	}
+}
@@ -4,4 +4,4 @@ go 1.23.0
 
 toolchain go1.23.6
 
-require golang.org/x/exp v0.0.0-20250218142911-aa4b98e5adaa // indirect
+require golang.org/x/exp v0.0.0-20250218142911-aa4b98e5adaa
@@ -11,6 +11,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"runtime"
 	"time"
 )
 
@@ -21,7 +22,7 @@ func main() {
 	config := flag.String("config", "test.json",
 		"JSON configuration for the test.")
 	compare := flag.Bool("compare", false,
-		"Compare with Top Down method? Default False.")
+		"Compare with Top Down, Binned SAH and Linear methods? Default False.")
 	flag.Parse()
 	configFile, err := os.Open(*config)
 	if err != nil {
@@ -66,10 +67,19 @@ func (b *bvhTest[T]) comparisonTest() {
 		orths = append(orths, orth)
 
 		iter.Add(orth)
-		bvol2 := bvh.TopDownBVH(orths)
+		bvol2 := bvh.TopDownBVHParallel(orths, runtime.NumCPU())
+		bvol3 := bvh.BinnedSAHBVH(orths, 4)
 
-		fmt.Printf("%d, %d, %d, %d, %d\n", a, bvol.GetDepth(), iter.Score(),
-			bvol2.GetDepth(), bvol2.Score())
+		// Time the linear BVH, since it is intended for bulk loading.
+		t := time.Now()
+		bvol4 := bvh.LinearBVH(orths)
+		linear := time.Now().Sub(t).Nanoseconds()
+		bvol4.OptimizeTreelets()
+		optimized := time.Now().Sub(t).Nanoseconds()
+
+		fmt.Printf("%d, %d, %v, %d, %v, %d, %v, %d, %v, %d, %d\n", a, bvol.GetDepth(), iter.Score(),
+			bvol2.GetDepth(), bvol2.Score(), bvol3.GetDepth(), bvol3.Score(),
+			bvol4.GetDepth(), bvol4.Score(), linear, optimized)
 	}
 }
 
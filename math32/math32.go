@@ -1,43 +1,211 @@
 package math32
 
-import "math"
+import (
+	"math"
+	"unsafe"
+)
 
 // SHIFT represents the number of bits in a 32 bit int minus 1
 const SHIFT uint = 31
 
 func MaxValue[T Number]() T {
-	var t T
 	var maxFloat32 = math.MaxFloat32
 	var maxFloat64 = math.MaxFloat64
-	var maxInt64 = math.MaxInt64
-	switch any(t).(type) {
-	case float32:
+	var maxInt64 int64 = math.MaxInt64
+	switch kindOf[T]() {
+	case KindFloat32:
 		return T(maxFloat32) // ✅ Float32 max
-	case float64:
+	case KindFloat64:
 		return T(maxFloat64) // ✅ Float64 max
-	case int32:
+	case KindInt32:
 		return T(math.MaxInt32) // ✅ Int32 max (converts int → int32)
-	case int64:
+	case KindInt64:
 		return T(maxInt64) // ✅ Int64 max (converts int → int64)
 	default:
 		panic("unsupported type")
 	}
 }
 
-func NegativeOne[T Number]() T {
+// Kind is the representation of a Number, such as to encode its values.
+type Kind uint8
+
+const (
+	KindInt32 Kind = iota
+	KindInt64
+	KindFloat32
+	KindFloat64
+	// KindFixed is a fixed point type whose 1 is not the raw value 1 (see Unit), such as fixed.Fixed.
+	KindFixed
+)
+
+// KindOf returns the representation of T. Look it up once rather than for every value, since fixed point types are
+// found by their Unit method.
+func KindOf[T Number]() Kind {
+	if k := kindOf[T](); k != KindInt64 || Unit[T]() == 1 {
+		return k
+	}
+	return KindFixed
+}
+
+// kindOf returns the underlying type of T, which is also correct for named types such as fixed.Fixed. It avoids
+// reflection, since it is called for every score and sweep: only floats keep a half, and the size gives the bits.
+func kindOf[T Number]() Kind {
 	var t T
-	switch any(t).(type) {
-	case float32:
-		return T(-1.0) // ✅ -1.0 for float32
-	case float64:
-		return T(-1.0) // ✅ -1.0 for float64
-	case int32:
-		return T(-1) // ✅ -1 for int32
-	case int64:
-		return T(-1) // ✅ -1 for int64
+	half := 0.5
+	k := KindInt32
+	if T(half) != 0 {
+		k = KindFloat32
+	}
+	if unsafe.Sizeof(t) == 8 {
+		k++
+	}
+	return k
+}
+
+func NegativeOne[T Number]() T {
+	return T(-1)
+}
+
+// MaxCoordinate is the largest magnitude of a coordinate in a validated volume (see Orthotope.Validate). Keeping both
+// corners within it means the distance between any two coordinates, such as the delta of bounds, does not overflow.
+func MaxCoordinate[T Number]() T {
+	return MaxValue[T]() / 2
+}
+
+// AddSat adds a and b, clamping integers to -MaxValue or MaxValue instead of wrapping around
+func AddSat[T Number](a, b T) T {
+	sum := a + b
+	if b > 0 && sum < a {
+		return MaxValue[T]()
+	} else if b < 0 && sum > a {
+		return -MaxValue[T]()
+	}
+	return sum
+}
+
+// SubSat subtracts b from a, clamping integers to -MaxValue or MaxValue instead of wrapping around.
+func SubSat[T Number](a, b T) T {
+	diff := a - b
+	if b < 0 && diff < a {
+		return MaxValue[T]()
+	} else if b > 0 && diff > a {
+		return -MaxValue[T]()
+	}
+	return diff
+}
+
+// IsFloat returns true if T is a floating point type, rather than an integer or fixed point type.
+func IsFloat[T Number]() bool {
+	return kindOf[T]() >= KindFloat32
+}
+
+// SquareSat returns x * x for builtin types, clamping integers to MaxValue instead of wrapping around. Types with their
+// own multiplication should use it instead (see MulFunc).
+func SquareSat[T Number](x T) T {
+	var limit T
+	switch kindOf[T]() {
+	case KindInt32:
+		limit = 46340 // The largest int32 whose square is an int32.
+	case KindInt64:
+		var root int64 = 3037000499
+		limit = T(root)
 	default:
-		panic("unsupported type")
+		return x * x
+	}
+	if x > limit || x < -limit {
+		return MaxValue[T]()
+	}
+	return x * x
+}
+
+// multiplier is implemented by Numbers that need their own multiplication, such as fixed point types, for which the
+// product of the raw values has the wrong scale.
+type multiplier[T any] interface {
+	Mul(T) T
+}
+
+// Mul returns a * b, using the Mul method of types that have one.
+func Mul[T Number](a, b T) T {
+	if m, ok := any(a).(multiplier[T]); ok {
+		return m.Mul(b)
+	}
+	return a * b
+}
+
+// MulFunc returns the Mul method of types that have one, or nil for types whose product is exact. Checking it once
+// rather than calling Mul keeps loops over builtin types fast.
+func MulFunc[T Number]() func(a, b T) T {
+	var zero T
+	if _, ok := any(zero).(multiplier[T]); ok {
+		return func(a, b T) T {
+			return any(a).(multiplier[T]).Mul(b)
+		}
+	}
+	return nil
+}
+
+// divider is implemented by Numbers that need their own division, such as fixed point types.
+type divider[T any] interface {
+	Div(T) T
+}
+
+// DivFunc returns the Div method of types that have one, or nil for types whose quotient is correct with /. See
+// MulFunc.
+func DivFunc[T Number]() func(a, b T) T {
+	var zero T
+	if _, ok := any(zero).(divider[T]); ok {
+		return func(a, b T) T {
+			return any(a).(divider[T]).Div(b)
+		}
+	}
+	return nil
+}
+
+// unit is implemented by Numbers whose 1 is not the raw value 1, such as fixed point types.
+type unit[T any] interface {
+	Unit() T
+}
+
+// Unit returns 1 as a T, which is the end of the movement for Intersects.
+func Unit[T Number]() T {
+	var zero T
+	if u, ok := any(zero).(unit[T]); ok {
+		return u.Unit()
+	}
+	return 1
+}
+
+// Finite returns false for NaN and infinite values. Integers are always finite.
+func Finite[T Number](x T) bool {
+	return x-x == 0
+}
+
+// SmallestNormal returns the smallest positive normal value of T, or 1 for integers. Smaller (subnormal) values lose
+// precision and overflow when divided by.
+func SmallestNormal[T Number]() T {
+	var normal32 float32 = 0x1p-126
+	var normal64 float64 = 0x1p-1022
+	switch kindOf[T]() {
+	case KindFloat32:
+		return T(normal32)
+	case KindFloat64:
+		return T(normal64)
+	default:
+		return T(1)
+	}
+}
+
+// span returns high - low, rounded up such that low + span >= high despite floating point rounding.
+func span[T Number](low, high T) T {
+	delta := high - low
+	for low+delta < high {
+		if kindOf[T]() == KindFloat32 {
+			delta = T(math.Nextafter32(float32(delta), float32(math.Inf(1))))
+		} else {
+			delta = T(math.Nextafter(float64(delta), math.Inf(1)))
+		}
 	}
+	return delta
 }
 
 // Float32Max use for efficient branchless calculations
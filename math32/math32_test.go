@@ -2,6 +2,7 @@ package math32
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -132,3 +133,92 @@ func TestInt32Abs(t *testing.T) {
 		t.Errorf("Expected %d, got %d.", expected, actual)
 	}
 }
+
+func TestAddSat(t *testing.T) {
+	if sum := AddSat[int32](math.MaxInt32-1, 5); sum != math.MaxInt32 {
+		t.Errorf("Expected %d, got %d", int32(math.MaxInt32), sum)
+	}
+	if sum := AddSat[int32](math.MinInt32+1, -5); sum != -math.MaxInt32 {
+		t.Errorf("Expected %d, got %d", -math.MaxInt32, sum)
+	}
+	if sum := AddSat[int64](math.MaxInt64, math.MaxInt64); sum != math.MaxInt64 {
+		t.Errorf("Expected %d, got %d", int64(math.MaxInt64), sum)
+	}
+	if sum := AddSat[int64](-7, 3); sum != -4 {
+		t.Errorf("Expected -4, got %d", sum)
+	}
+	if sum := AddSat[float32](1.5, 2); sum != 3.5 {
+		t.Errorf("Expected 3.5, got %v", sum)
+	}
+	if diff := SubSat[int32](math.MaxInt32-1, -5); diff != math.MaxInt32 {
+		t.Errorf("Expected %d, got %d", int32(math.MaxInt32), diff)
+	}
+	if diff := SubSat[int64](math.MinInt64+1, 5); diff != -math.MaxInt64 {
+		t.Errorf("Expected %d, got %d", int64(-math.MaxInt64), diff)
+	}
+	if diff := SubSat[float64](1.5, 2); diff != -0.5 {
+		t.Errorf("Expected -0.5, got %v", diff)
+	}
+	if limit := MaxCoordinate[int32](); limit != math.MaxInt32/2 {
+		t.Errorf("Expected %d, got %d", math.MaxInt32/2, limit)
+	}
+}
+
+func TestSquareSat(t *testing.T) {
+	cases := []struct{ x, expected int64 }{
+		{46340, 46340 * 46340}, {-46340, 46340 * 46340}, {46341, math.MaxInt32}, {math.MinInt32, math.MaxInt32},
+	}
+	for _, c := range cases {
+		if square := SquareSat(int32(c.x)); int64(square) != c.expected {
+			t.Errorf("Expected %d squared to be %d, got %d", c.x, c.expected, square)
+		}
+	}
+	if square := SquareSat[int64](3037000499); square != 3037000499*3037000499 {
+		t.Errorf("Expected the square of 3037000499, got %d", square)
+	}
+	if square := SquareSat[int64](-3037000500); square != math.MaxInt64 {
+		t.Errorf("Expected %d, got %d", int64(math.MaxInt64), square)
+	}
+	if square := SquareSat[float32](1e30); !math.IsInf(float64(square), 1) {
+		t.Errorf("Expected floats to overflow to infinity, got %v", square)
+	}
+}
+
+// tenths is a named Number with its own multiplication and unit, like a fixed point type.
+type tenths int64
+
+func (t tenths) Mul(other tenths) tenths {
+	return t * other / 10
+}
+
+func (tenths) Unit() tenths {
+	return 10
+}
+
+func TestNamedNumbers(t *testing.T) {
+	if max := MaxValue[tenths](); max != math.MaxInt64 {
+		t.Errorf("Expected %d, got %d", int64(math.MaxInt64), max)
+	}
+	if sum := AddSat[tenths](math.MaxInt64-1, 5); sum != math.MaxInt64 {
+		t.Errorf("Expected the sum to saturate, got %d", sum)
+	}
+	if product := Mul[tenths](25, 20); product != 50 {
+		t.Errorf("Expected 2.5 * 2 = 5.0, got %d", product)
+	}
+	if mul := MulFunc[tenths](); mul == nil || mul(25, 20) != 50 {
+		t.Errorf("Expected the Mul method")
+	}
+	if MulFunc[float32]() != nil || Mul[int32](25, 20) != 500 {
+		t.Errorf("Expected builtin multiplication")
+	}
+	if Unit[tenths]() != 10 || Unit[float64]() != 1 {
+		t.Errorf("Unexpected units %d and %v", Unit[tenths](), Unit[float64]())
+	}
+	kinds := []Kind{kindOf[int32](), kindOf[int64](), kindOf[float32](), kindOf[float64](), kindOf[tenths]()}
+	if !reflect.DeepEqual(kinds, []Kind{KindInt32, KindInt64, KindFloat32, KindFloat64, KindInt64}) {
+		t.Errorf("Unexpected kinds %v", kinds)
+	}
+	if KindOf[tenths]() != KindFixed || KindOf[int64]() != KindInt64 || KindOf[float32]() != KindFloat32 {
+		t.Errorf("Unexpected kinds %v, %v and %v", KindOf[tenths](), KindOf[int64](), KindOf[float32]())
+	}
+}
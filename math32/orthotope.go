@@ -16,6 +16,21 @@ type Orthotope[T Number] struct {
 	Delta [DIMENSIONS]T
 }
 
+// NewOrthotope returns an orthotope at point with size delta, or an error if it is invalid (see Validate).
+func NewOrthotope[T Number](point, delta Coordinate[T]) (*Orthotope[T], error) {
+	o := &Orthotope[T]{Point: point, Delta: delta}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Validate returns ErrNotFinite, ErrNegativeDelta or ErrOverflow unless both corners of o are within MaxCoordinate.
+// Overlaps, Contains and MinBounds of valid orthotopes do not overflow.
+func (o *Orthotope[T]) Validate() error {
+	return validateBounds[T](o.Point, o.Delta)
+}
+
 func (o *Orthotope[T]) GetPoint() Coordinate[T] {
 	return o.Point
 }
@@ -27,6 +42,11 @@ func (o *Orthotope[T]) New() VolumeType[T] {
 	return &Orthotope[T]{}
 }
 
+// CopyFrom sets o to the bounds of src.
+func (o *Orthotope[T]) CopyFrom(src VolumeType[T]) {
+	o.Point, o.Delta = src.GetPoint(), src.GetDelta()
+}
+
 // Overlaps returns true if two orthotopes intersect
 func (o *Orthotope[T]) Overlaps(other VolumeType[T]) bool {
 	intersects := true
@@ -68,7 +88,8 @@ func (o *Orthotope[T]) TaxiPath(point Coordinate[T]) Coordinate[T] {
 	return coor
 }
 
-// Intersects return 0 <= t <= 1 for where the orth intersects along the delta, else t = 2 when there's no intersection
+// Intersects return 0 <= t <= 1 for where the orth intersects along the delta, else t = 2 when there's no intersection.
+// Non-finite deltas do not intersect.
 func (o *Orthotope[T]) Intersects(other VolumeType[T], delta *Coordinate[T]) T {
 	otherPoint := other.GetPoint()
 	otherDelta := other.GetDelta()
@@ -79,7 +100,10 @@ func (o *Orthotope[T]) Intersects(other VolumeType[T], delta *Coordinate[T]) T {
 	for index, p0 := range otherPoint {
 		p1 := otherDelta[index] + p0
 
-		if delta[index] == 0 {
+		if !Finite(delta[index]) {
+			return T(2)
+		} else if Abs(delta[index]) < SmallestNormal[T]() {
+			// Zero and subnormal directions are parallel to the slab, since dividing by them overflows.
 			if o.Point[index] > p1 || p0 > o.Point[index]+o.Delta[index] {
 				return T(2)
 			}
@@ -94,13 +118,14 @@ func (o *Orthotope[T]) Intersects(other VolumeType[T], delta *Coordinate[T]) T {
 			inT = Max(inT, p0T)
 			outT = Min(outT, p1T)
 
-			if inT > outT {
+			// Written such that NaN (from non-finite volumes) does not intersect.
+			if !(inT <= outT) {
 				return T(2) // supposed to be 2 but just leaving it for testing
 			}
 		}
 	}
 
-	if inT < 0 {
+	if !(inT >= 0) {
 		return T(2)
 	}
 	return inT
@@ -139,7 +164,7 @@ func (o *Orthotope[T]) Slide(delta *Coordinate[T], order [DIMENSIONS]int, margin
 }
 
 // MinBounds modifies point and delta such to that the resulting orthotope is the smallest one that can possibly contain
-// all others
+// all others. Floating point deltas are rounded up, so that the bounds still contain the others.
 func (o *Orthotope[T]) MinBounds(others ...VolumeType[T]) {
 
 	if len(others) == 0 {
@@ -166,16 +191,28 @@ func (o *Orthotope[T]) MinBounds(others ...VolumeType[T]) {
 			}
 		}
 		o.Point[i] = min
-		o.Delta[i] = max - min
+		o.Delta[i] = span(min, max)
+	}
+}
 
+// MinBoundsPair is equivalent to MinBounds(first, second), but does not allocate.
+func (o *Orthotope[T]) MinBoundsPair(first, second VolumeType[T]) {
+	firstPoint, firstDelta := first.GetPoint(), first.GetDelta()
+	secondPoint, secondDelta := second.GetPoint(), second.GetDelta()
+	for i := 0; i < DIMENSIONS; i++ {
+		min := Min(firstPoint[i], secondPoint[i])
+		max := Max(firstPoint[i]+firstDelta[i], secondPoint[i]+secondDelta[i])
+		o.Point[i] = min
+		o.Delta[i] = span(min, max)
 	}
 }
 
-// Score adds the lengths of the sides. This is the heuristic used to rebalance collision.BVol objects via swapChecks
+// Score adds the lengths of the sides. This is the heuristic used to rebalance collision.BVol objects via swapChecks.
+// The sum saturates at MaxValue rather than overflowing.
 func (o *Orthotope[T]) Score() T {
 	var score T
 	for _, d := range o.Delta {
-		score += d
+		score = AddSat(score, d)
 	}
 	return score
 }
@@ -1,6 +1,9 @@
 package math32
 
 import (
+	"errors"
+	"math"
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
@@ -92,6 +95,23 @@ func TestMinBounds(t *testing.T) {
 	}
 }
 
+func TestMinBoundsPair(t *testing.T) {
+	o1 := &Orthotope[int32]{Point: Coordinate[int32]{10, -20, 0}, Delta: Coordinate[int32]{30, 30, 0}}
+	o2 := &Orthotope[int32]{Point: Coordinate[int32]{-10, 5, 0}, Delta: Coordinate[int32]{30, 30, 0}}
+
+	actual := &Orthotope[int32]{}
+	actual.MinBoundsPair(o1, o2)
+	expected := &Orthotope[int32]{}
+	expected.MinBounds(o1, o2)
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v.", expected, actual)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { actual.MinBoundsPair(o1, o2) }); allocs != 0 {
+		t.Errorf("Expected no allocations, got %v.", allocs)
+	}
+}
+
 func TestOrthString(t *testing.T) {
 	o1 := &Orthotope[int32]{Point: Coordinate[int32]{10, -20}, Delta: Coordinate[int32]{30, 30}}
 
@@ -119,3 +139,166 @@ func TestOrthEquals(t *testing.T) {
 		t.Errorf("%v should not equal %v", o4, o3)
 	}
 }
+
+func TestNewOrthotope(t *testing.T) {
+	limit := MaxCoordinate[int32]()
+	valid := [][2]Coordinate[int32]{
+		{{-limit, -limit, -limit}, {2 * limit, 2 * limit, 2 * limit}},
+		{{limit, limit, limit}, {0, 0, 0}},
+		{{-5, 0, limit - 10}, {5, 0, 10}},
+	}
+	for _, c := range valid {
+		if o, err := NewOrthotope(c[0], c[1]); err != nil || o.Point != c[0] || o.Delta != c[1] {
+			t.Errorf("Unable to create an orthotope at %v with delta %v: %v", c[0], c[1], err)
+		}
+	}
+
+	invalid := []struct {
+		point, delta Coordinate[int32]
+		err          error
+	}{
+		{Coordinate[int32]{0, 0, 0}, Coordinate[int32]{1, -1, 1}, ErrNegativeDelta},
+		{Coordinate[int32]{limit, 0, 0}, Coordinate[int32]{1, 0, 0}, ErrOverflow},
+		{Coordinate[int32]{0, 0, 1}, Coordinate[int32]{0, 0, math.MaxInt32}, ErrOverflow},
+		{Coordinate[int32]{0, math.MinInt32, 0}, Coordinate[int32]{0, 1, 0}, ErrOverflow},
+		{Coordinate[int32]{math.MaxInt32, 0, 0}, Coordinate[int32]{0, 0, 0}, ErrOverflow},
+	}
+	for _, c := range invalid {
+		if o, err := NewOrthotope(c.point, c.delta); o != nil || !errors.Is(err, c.err) {
+			t.Errorf("Expected %v for point %v with delta %v, got %v", c.err, c.point, c.delta, err)
+		}
+	}
+
+	large := MaxCoordinate[float64]()
+	if _, err := NewOrthotope(Coordinate[float64]{-large}, Coordinate[float64]{2 * large}); err != nil {
+		t.Errorf("Unable to create an orthotope spanning float64: %v", err)
+	}
+	if _, err := NewOrthotope(Coordinate[float64]{large}, Coordinate[float64]{large}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestExtremeOrthotopes(t *testing.T) {
+	limit := MaxCoordinate[int32]()
+	low, _ := NewOrthotope(Coordinate[int32]{-limit, -limit, -limit}, Coordinate[int32]{limit, limit, limit})
+	high, _ := NewOrthotope(Coordinate[int32]{1, 1, 1}, Coordinate[int32]{limit - 1, limit - 1, limit - 1})
+	edge, _ := NewOrthotope(Coordinate[int32]{limit, limit, limit}, Coordinate[int32]{})
+
+	if low.Overlaps(high) || high.Overlaps(low) || !high.Overlaps(edge) || low.Contains(edge) {
+		t.Errorf("Unexpected overlap between %v, %v and %v", low, high, edge)
+	}
+
+	bounds := &Orthotope[int32]{}
+	bounds.MinBounds(low, high, edge)
+	expected := &Orthotope[int32]{Point: low.Point, Delta: Coordinate[int32]{2 * limit, 2 * limit, 2 * limit}}
+	if !bounds.Equals(expected) || bounds.Validate() != nil {
+		t.Errorf("Expected bounds of %v, got %v", expected, bounds)
+	}
+	if !bounds.Contains(low) || !bounds.Contains(high) || !bounds.Contains(edge) {
+		t.Errorf("Bounds %v do not contain their volumes", bounds)
+	}
+
+	// Summing the three deltas exceeds an int32.
+	if score := bounds.Score(); score != math.MaxInt32 {
+		t.Errorf("Expected the score to saturate at %d, got %d", int32(math.MaxInt32), score)
+	}
+	flat := &Orthotope[int32]{Delta: Coordinate[int32]{limit, limit, 0}}
+	if score := flat.Score(); score != 2*limit {
+		t.Errorf("Expected %d, got %d", 2*limit, score)
+	}
+}
+
+func TestValidateNotFinite(t *testing.T) {
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	for _, o := range []*Orthotope[float32]{
+		{Point: Coordinate[float32]{nan}},
+		{Point: Coordinate[float32]{0, -inf}},
+		{Delta: Coordinate[float32]{0, 0, inf}},
+		{Delta: Coordinate[float32]{nan, 1, 1}},
+	} {
+		if err := Validate[float32](o); !errors.Is(err, ErrNotFinite) {
+			t.Errorf("Expected ErrNotFinite for %v, got %v", o, err)
+		}
+	}
+	negative := &Orthotope[float32]{Delta: Coordinate[float32]{1, float32(math.Copysign(0, -1)), -1e-30}}
+	if err := Validate[float32](negative); !errors.Is(err, ErrNegativeDelta) {
+		t.Errorf("Expected ErrNegativeDelta for %v, got %v", negative, err)
+	}
+	large := &Orthotope[float32]{Point: Coordinate[float32]{-1e30}, Delta: Coordinate[float32]{1e30}}
+	if err := Validate[float32](large); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := Validate[float64](&Sphere[float64]{Radius: math.NaN()}); !errors.Is(err, ErrNotFinite) {
+		t.Errorf("Expected ErrNotFinite for a sphere, got %v", err)
+	}
+}
+
+func TestMinBoundsRoundsOut(t *testing.T) {
+	// high - low rounds down, such that low + (high - low) < high.
+	low, high := math.Float32frombits(0xc9828f4b), math.Float32frombits(0x3a3838c6)
+	o1 := &Orthotope[float32]{Point: Coordinate[float32]{low, 0, 0}, Delta: Coordinate[float32]{0, 1, 1}}
+	o2 := &Orthotope[float32]{Point: Coordinate[float32]{high, 0, 0}, Delta: Coordinate[float32]{0, 1, 1}}
+	if low+(high-low) >= high {
+		t.Fatalf("Expected %v - %v to round down", high, low)
+	}
+
+	bounds, pair := &Orthotope[float32]{}, &Orthotope[float32]{}
+	bounds.MinBounds(o1, o2)
+	pair.MinBoundsPair(o2, o1)
+	for _, b := range []*Orthotope[float32]{bounds, pair} {
+		if !b.Contains(o1) || !b.Contains(o2) || b.Delta[0] != math.Nextafter32(high-low, 1e10) {
+			t.Errorf("Bounds %v do not contain %v and %v", b, o1, o2)
+		}
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		orths := make([]VolumeType[float32], 3)
+		for o := range orths {
+			orth := &Orthotope[float32]{}
+			for d := range orth.Point {
+				orth.Point[d] = float32(r.NormFloat64() * math.Pow(10, float64(r.Intn(12)-4)))
+				orth.Delta[d] = float32(r.ExpFloat64())
+			}
+			orths[o] = orth
+		}
+		bounds.MinBounds(orths...)
+		for _, orth := range orths {
+			if !bounds.Contains(orth) {
+				t.Fatalf("Bounds %v do not contain %v", bounds, orth)
+			}
+		}
+	}
+}
+
+func TestIntersectsEdgeCases(t *testing.T) {
+	o := &Orthotope[float32]{Point: Coordinate[float32]{0, 0, 0}, Delta: Coordinate[float32]{1, 1, 1}}
+	wall := &Orthotope[float32]{Point: Coordinate[float32]{5, 0, 0}, Delta: Coordinate[float32]{1, 1, 1}}
+	subnormal := math.Float32frombits(1)
+	cases := []struct {
+		delta    Coordinate[float32]
+		expected float32
+	}{
+		{Coordinate[float32]{}, 2},                                   // Not moving, and not overlapping.
+		{Coordinate[float32]{8, 0, 0}, 0.5},                          // Moving along parallel slabs.
+		{Coordinate[float32]{8, subnormal, -subnormal}, 0.5},         // Subnormal directions are parallel.
+		{Coordinate[float32]{8, float32(math.Copysign(0, -1))}, 0.5}, // As is negative zero.
+		{Coordinate[float32]{float32(math.Inf(1))}, 2},
+		{Coordinate[float32]{float32(math.NaN())}, 2},
+		{Coordinate[float32]{8, 0, 3}, 2}, // Passes over the wall.
+	}
+	for _, c := range cases {
+		if actual := wall.Intersects(o, &c.delta); actual != c.expected {
+			t.Errorf("Expected %v moving %v, got %v", c.expected, c.delta, actual)
+		}
+	}
+
+	// A subnormal movement into an adjacent wall intersects immediately, rather than overflowing.
+	touching := &Orthotope[float32]{Point: Coordinate[float32]{1, 0, 0}, Delta: Coordinate[float32]{1, 1, 1}}
+	if actual := touching.Intersects(o, &Coordinate[float32]{subnormal}); actual != 0 {
+		t.Errorf("Expected 0, got %v", actual)
+	}
+	if actual := o.Intersects(o, &Coordinate[float32]{}); actual != 0 {
+		t.Errorf("Expected a stationary orthotope to intersect itself at 0, got %v", actual)
+	}
+}
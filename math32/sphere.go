@@ -10,6 +10,38 @@ type Sphere[T Number] struct {
 	Radius T
 }
 
+// NewSphere returns a sphere at center with radius, or an error if it is invalid (see Validate).
+func NewSphere[T Number](center Coordinate[T], radius T) (*Sphere[T], error) {
+	s := &Sphere[T]{Center: center, Radius: radius}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate returns ErrNotFinite, ErrNegativeDelta or ErrOverflow unless the bounds of s (see GetPoint and GetDelta)
+// are within MaxCoordinate.
+func (s *Sphere[T]) Validate() error {
+	finite := Finite(s.Radius)
+	for _, c := range s.Center {
+		finite = finite && Finite(c)
+	}
+	if !finite {
+		return fmt.Errorf("%w: center %v, radius %v", ErrNotFinite, s.Center, s.Radius)
+	}
+	if s.Radius < 0 {
+		return fmt.Errorf("%w: radius %v", ErrNegativeDelta, s.Radius)
+	}
+	limit := MaxCoordinate[T]()
+	for d, c := range s.Center {
+		// Compare against the distance to the limits, since Center +/- Radius may itself overflow.
+		if c < -limit || c > limit || s.Radius > limit-c || s.Radius > limit+c {
+			return fmt.Errorf("%w: center %v, radius %v in dimension %d", ErrOverflow, c, s.Radius, d)
+		}
+	}
+	return nil
+}
+
 func (s *Sphere[T]) GetCenter() Coordinate[T] {
 	return s.Center
 }
@@ -75,8 +107,40 @@ func (s *Sphere[T]) MinBounds(volumes ...VolumeType[T]) {
 	}
 }
 
+// MinBoundsPair is equivalent to MinBounds(first, second), but does not allocate.
+func (s *Sphere[T]) MinBoundsPair(first, second VolumeType[T]) {
+	var scale = 0.5
+	a, aOk := first.(*Sphere[T])
+	b, bOk := second.(*Sphere[T])
+	if !aOk || !bOk {
+		if aOk {
+			*s = *a
+		} else if bOk {
+			*s = *b
+		}
+		return
+	}
+
+	// The pair is the farthest apart, see MinBounds.
+	var c1, c2 Coordinate[T]
+	maxDist := Distance(a.Center, b.Center) + a.Radius + b.Radius
+	if maxDist > 0 {
+		c1, c2 = a.Center, b.Center
+	} else {
+		maxDist = 0
+	}
+	s.Center = c1.Add(c2.Sub(c1).Scale(T(scale)))
+	s.Radius = maxDist / 2
+
+	for _, sphere := range [2]*Sphere[T]{a, b} {
+		if requiredRadius := Distance(s.Center, sphere.Center) + sphere.Radius; requiredRadius > s.Radius {
+			s.Radius = requiredRadius
+		}
+	}
+}
+
 func (s *Sphere[T]) Score() T {
-	return s.Radius * 2
+	return AddSat(s.Radius, s.Radius)
 }
 
 func (s *Sphere[T]) Equals(other VolumeType[T]) bool {
@@ -120,6 +184,15 @@ func (s *Sphere[T]) Intersects(other VolumeType[T], delta *Coordinate[T]) T {
 	a := rayDir.Dot(rayDir)
 	b := 2.0 * oc.Dot(rayDir)
 	c := oc.Dot(oc) - combinedRadius*combinedRadius
+	if !Finite(a) {
+		return 2.0
+	} else if a < SmallestNormal[T]() {
+		// Without (or with a vanishingly small) movement, the spheres only intersect if they already overlap.
+		if c <= 0 {
+			return 0
+		}
+		return 2.0
+	}
 	discriminant := b*b - 4*a*c
 	if discriminant < 0 {
 		return 2.0
@@ -165,6 +238,13 @@ func (s *Sphere[T]) New() VolumeType[T] {
 	return &Sphere[T]{}
 }
 
+// CopyFrom sets s to a copy of src, if it is a sphere.
+func (s *Sphere[T]) CopyFrom(src VolumeType[T]) {
+	if other, ok := src.(*Sphere[T]); ok {
+		*s = *other
+	}
+}
+
 func Distance[T Number](a, b Coordinate[T]) T {
 	return T(math.Sqrt(float64(a.DistanceSq(b))))
 }
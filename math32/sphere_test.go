@@ -1,6 +1,9 @@
 package math32
 
 import (
+	"errors"
+	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -89,6 +92,45 @@ func TestSphereMinBounds(t *testing.T) {
 			expectedCenter, expectedRadius, container.Center, container.Radius)
 	}
 }
+func TestSphereMinBoundsPair(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	pair, expected := &Sphere[float32]{}, &Sphere[float32]{}
+	for i := 0; i < 1000; i++ {
+		s1 := &Sphere[float32]{Center: Coordinate[float32]{r.Float32() * 100, r.Float32() * 100}, Radius: r.Float32()}
+		s2 := &Sphere[float32]{Center: Coordinate[float32]{r.Float32() * 100, r.Float32() * 100}, Radius: r.Float32()}
+		pair.MinBoundsPair(s1, s2)
+		expected.MinBounds(s1, s2)
+		if !pair.Equals(expected) {
+			t.Fatalf("Expected %v, got %v.", expected, pair)
+		}
+	}
+
+	s1 := &Sphere[int32]{Center: Coordinate[int32]{4, 4}, Radius: 0}
+	s2 := &Sphere[int32]{Center: Coordinate[int32]{10, 0}, Radius: 3}
+	actual, bounds := &Sphere[int32]{}, &Sphere[int32]{}
+	actual.MinBoundsPair(s1, s2)
+	bounds.MinBounds(s1, s2)
+	if !actual.Equals(bounds) {
+		t.Errorf("Expected %v, got %v.", bounds, actual)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { actual.MinBoundsPair(s1, s2) }); allocs != 0 {
+		t.Errorf("Expected no allocations, got %v.", allocs)
+	}
+}
+
+func TestSphereCopyFrom(t *testing.T) {
+	src := &Sphere[float32]{Center: Coordinate[float32]{1, 2, 3}, Radius: 4}
+	dst := src.New().(*Sphere[float32])
+	dst.CopyFrom(src)
+	if *dst != *src {
+		t.Errorf("Expected a copy of %v, got %v", src.String(), dst.String())
+	}
+	dst.CopyFrom(&Orthotope[float32]{})
+	if *dst != *src {
+		t.Errorf("Copying an orthotope changed the sphere to %v", dst.String())
+	}
+}
+
 func TestSphereString(t *testing.T) {
 	s := &Sphere[float32]{Center: Coordinate[float32]{1.5, -2.5, 0}, Radius: 3.0}
 	expected := "Center [1.5 -2.5 0], Radius 3"
@@ -108,3 +150,55 @@ func TestSphereEquals(t *testing.T) {
 		t.Error("Should not equal similar sphere")
 	}
 }
+
+func TestNewSphere(t *testing.T) {
+	limit := MaxCoordinate[int32]()
+	sphere, err := NewSphere(Coordinate[int32]{0, limit / 2, -limit / 2}, limit/2)
+	if err != nil || sphere.Score() != limit/2*2 {
+		t.Errorf("Unable to create a sphere: %v", err)
+	}
+	if _, err := NewSphere(Coordinate[int32]{}, -1); !errors.Is(err, ErrNegativeDelta) {
+		t.Errorf("Expected ErrNegativeDelta, got %v", err)
+	}
+	for _, center := range []Coordinate[int32]{{limit}, {0, -limit}, {0, 0, math.MinInt32}} {
+		if _, err := NewSphere(center, 1); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Expected ErrOverflow for a sphere at %v, got %v", center, err)
+		}
+	}
+
+	// The score saturates for spheres that were not validated.
+	large := &Sphere[int32]{Radius: math.MaxInt32 - 1}
+	if score := large.Score(); score != math.MaxInt32 {
+		t.Errorf("Expected %d, got %d", int32(math.MaxInt32), score)
+	}
+}
+
+func TestSphereIntersectsEdgeCases(t *testing.T) {
+	s1 := &Sphere[float64]{Center: Coordinate[float64]{0, 0, 0}, Radius: 1}
+	s2 := &Sphere[float64]{Center: Coordinate[float64]{5, 0, 0}, Radius: 1}
+	s3 := &Sphere[float64]{Center: Coordinate[float64]{1, 1, 0}, Radius: 1}
+	cases := []struct {
+		other    *Sphere[float64]
+		delta    Coordinate[float64]
+		expected float64
+	}{
+		{s2, Coordinate[float64]{}, 2},
+		{s3, Coordinate[float64]{}, 0},
+		{s2, Coordinate[float64]{math.SmallestNonzeroFloat64}, 2},
+		{s2, Coordinate[float64]{math.Inf(-1)}, 2},
+		{s2, Coordinate[float64]{math.NaN()}, 2},
+		{s2, Coordinate[float64]{-6}, 0.5},
+	}
+	for _, c := range cases {
+		if actual := s1.Intersects(c.other, &c.delta); actual != c.expected {
+			t.Errorf("Expected %v moving %v by %v, got %v", c.expected, c.other, c.delta, actual)
+		}
+	}
+
+	// Integer spheres without movement do not divide by zero.
+	i1 := &Sphere[int32]{Radius: 2}
+	i2 := &Sphere[int32]{Center: Coordinate[int32]{3}, Radius: 2}
+	if actual := i1.Intersects(i2, &Coordinate[int32]{}); actual != 0 {
+		t.Errorf("Expected 0, got %v", actual)
+	}
+}
@@ -1,5 +1,29 @@
 package math32
 
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrOverflow is returned for volumes that reach beyond MaxCoordinate.
+	ErrOverflow = errors.New("math32: coordinate beyond MaxCoordinate")
+	// ErrNegativeDelta is returned for volumes with a negative delta or radius.
+	ErrNegativeDelta = errors.New("math32: negative delta")
+	// ErrNotFinite is returned for volumes with NaN or infinite coordinates.
+	ErrNotFinite = errors.New("math32: coordinate is not finite")
+)
+
+// Validate returns ErrNotFinite, ErrNegativeDelta or ErrOverflow for volumes that cannot be compared reliably, and
+// would corrupt the bounds of a BVH. Volumes with a Validate method (such as Orthotope and Sphere) use it, others are
+// checked by their bounds (see GetPoint and GetDelta).
+func Validate[E Number](v VolumeType[E]) error {
+	if validator, ok := v.(interface{ Validate() error }); ok {
+		return validator.Validate()
+	}
+	return validateBounds(v.GetPoint(), v.GetDelta())
+}
+
 type VolumeType[E Number] interface {
 	MinBounds(volumes ...VolumeType[E])
 	Score() E
@@ -14,3 +38,33 @@ type VolumeType[E Number] interface {
 	IsNil() bool
 	IsSame(VolumeType[E]) bool
 }
+
+// PairBounder is optionally implemented by volumes that can bound exactly two others without allocating the
+// variadic slice that MinBounds requires.
+type PairBounder[E Number] interface {
+	MinBoundsPair(first, second VolumeType[E])
+}
+
+// Copier is optionally implemented by volumes that can be set to a copy of another of the same type, such as to undo a
+// change that was rejected. Volumes without it are restored by bounding the copy alone (see MinBounds).
+type Copier[E Number] interface {
+	CopyFrom(src VolumeType[E])
+}
+
+// validateBounds checks that the near (point) and far (point + delta) corners are within MaxCoordinate.
+func validateBounds[T Number](point, delta Coordinate[T]) error {
+	limit := MaxCoordinate[T]()
+	for d := range point {
+		if !Finite(point[d]) || !Finite(delta[d]) {
+			return fmt.Errorf("%w: point %v, delta %v in dimension %d", ErrNotFinite, point[d], delta[d], d)
+		}
+		if delta[d] < 0 {
+			return fmt.Errorf("%w: %v in dimension %d", ErrNegativeDelta, delta[d], d)
+		}
+		// Compare against limit - point, since point + delta may itself overflow.
+		if point[d] < -limit || point[d] > limit || delta[d] > limit-point[d] {
+			return fmt.Errorf("%w: point %v, delta %v in dimension %d", ErrOverflow, point[d], delta[d], d)
+		}
+	}
+	return nil
+}
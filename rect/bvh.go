@@ -62,7 +62,7 @@ func TopDownBVH(orths []*Orthotope) *BVol {
 		sort.Sort(byDimension{orths: orths, dimension: d})
 		comp1.MinBounds(orths[:mid]...)
 		comp2.MinBounds(orths[mid:]...)
-		score := comp1.Score() + comp2.Score()
+		score := disc.AddSat(comp1.Score(), comp2.Score())
 		if score < lowScore {
 			lowScore = score
 			lowDim = d
@@ -126,7 +126,7 @@ func (bvol *BVol) redistribute() {
 func swapCheck(first *BVol, second *BVol, secIndex int) {
 	first.minBound()
 	second.minBound()
-	minScore := first.vol.Score() + second.vol.Score()
+	minScore := disc.AddSat(first.vol.Score(), second.vol.Score())
 	minIndex := -1
 
 	for index := 0; index < 2; index++ {
@@ -138,7 +138,7 @@ func swapCheck(first *BVol, second *BVol, secIndex int) {
 			// Score first then second, since first may be a child of second.
 			first.minBound()
 			second.minBound()
-			score := first.vol.Score() + second.vol.Score()
+			score := disc.AddSat(first.vol.Score(), second.vol.Score())
 			if score < minScore {
 				// Update the children with the best split
 				minScore = score
@@ -3,6 +3,8 @@ package rect
 
 import (
 	"math"
+
+	disc "github.com/briannoyama/bvh/discreet"
 )
 
 // OrthStack gives methods for working with Orthotope BVol.
@@ -15,6 +17,8 @@ type OrthStack interface {
 	Add(orth *Orthotope) bool
 	Contains(orth *Orthotope) bool
 	Remove(o *Orthotope) bool
+	Insert(orth *Orthotope) error
+	Delete(o *Orthotope) error
 }
 
 type orthStack struct {
@@ -286,7 +290,7 @@ func (s *orthStack) Score() int32 {
 	score := int32(0)
 
 	for s.HasNext() {
-		score += s.Next().vol.Score()
+		score = disc.AddSat(score, s.Next().vol.Score())
 	}
 	return score
 }
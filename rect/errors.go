@@ -0,0 +1,56 @@
+// Copyright 2018 Brian Noyama. Subject to the the Apache License, Version 2.0.
+package rect
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrDuplicate is returned when adding an orthotope that is already in the BVH.
+	ErrDuplicate = errors.New("rect: orthotope already added")
+	// ErrNotFound is returned when an orthotope is not in the BVH.
+	ErrNotFound = errors.New("rect: orthotope not found")
+	// ErrInvalidVolume is returned for nil orthotopes, and those rejected by Validate. The error from Validate is
+	// wrapped as well, so errors.Is also matches ErrNegativeDelta and ErrOverflow.
+	ErrInvalidVolume = errors.New("rect: invalid orthotope")
+	// ErrEmptyTree is returned when removing from a BVH without orthotopes.
+	ErrEmptyTree = errors.New("rect: empty tree")
+)
+
+// Insert adds an orthotope to a Bounding Volume Hierarchy like Add, but returns ErrInvalidVolume or ErrDuplicate
+// rather than false.
+func (s *orthStack) Insert(orth *Orthotope) error {
+	if orth == nil {
+		return fmt.Errorf("%w: nil", ErrInvalidVolume)
+	}
+	if err := orth.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidVolume, err)
+	}
+	if s.Contains(orth) || !s.Add(orth) {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Delete removes an orthotope from a Bounding Volume Hierarchy like Remove, but returns ErrEmptyTree or ErrNotFound
+// rather than false.
+func (s *orthStack) Delete(o *Orthotope) error {
+	if s.bvh.vol == nil {
+		return ErrEmptyTree
+	}
+	if o == nil || !s.Remove(o) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Insert an orthotope to a Bounding Volume Hierarchy (see orthStack.Insert). Only insert into the root volume.
+func (bvol *BVol) Insert(orth *Orthotope) error {
+	return bvol.Iterator().Insert(orth)
+}
+
+// Delete an orthotope from a Bounding Volume Hierarchy (see orthStack.Delete). Only delete from the root volume.
+func (bvol *BVol) Delete(o *Orthotope) error {
+	return bvol.Iterator().Delete(o)
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Brian Noyama. Subject to the the Apache License, Version 2.0.
+package rect
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestInsertDelete(t *testing.T) {
+	bvol := &BVol{}
+	orths := []*Orthotope{
+		{Point: [d]int32{0, 0, 0}, Delta: [d]int32{5, 5, 5}},
+		{Point: [d]int32{10, 0, 0}, Delta: [d]int32{5, 5, 5}},
+		{Point: [d]int32{0, 10, 0}, Delta: [d]int32{5, 5, 5}},
+		{Point: [d]int32{10, 10, 10}, Delta: [d]int32{5, 5, 5}},
+	}
+
+	if err := bvol.Delete(orths[0]); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Expected ErrEmptyTree, got %v", err)
+	}
+	for _, orth := range orths {
+		if err := bvol.Insert(orth); err != nil {
+			t.Errorf("Unable to insert %v: %v", orth, err)
+		}
+	}
+	iter := bvol.Iterator()
+	for _, orth := range orths {
+		if err := iter.Insert(orth); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Expected ErrDuplicate inserting %v, got %v", orth, err)
+		}
+	}
+
+	invalid := []struct {
+		orth *Orthotope
+		err  error
+	}{
+		{nil, ErrInvalidVolume},
+		{&Orthotope{Delta: [d]int32{-1}}, ErrNegativeDelta},
+		{&Orthotope{Point: [d]int32{MAXCOORD}, Delta: [d]int32{1}}, ErrOverflow},
+	}
+	for _, c := range invalid {
+		err := iter.Insert(c.orth)
+		if !errors.Is(err, ErrInvalidVolume) || !errors.Is(err, c.err) {
+			t.Errorf("Expected %v inserting %v, got %v", c.err, c.orth, err)
+		}
+	}
+
+	// An equal orthotope is not the same one.
+	copied := *orths[1]
+	if err := iter.Delete(&copied); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	for _, orth := range orths {
+		if err := iter.Delete(orth); err != nil {
+			t.Errorf("Unable to delete %v: %v", orth, err)
+		}
+	}
+	if err := bvol.Delete(orths[0]); !errors.Is(err, ErrEmptyTree) {
+		t.Errorf("Expected ErrEmptyTree after deleting every orthotope, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@
 package rect
 
 import (
+	"errors"
 	"fmt"
 	"math"
 
@@ -17,6 +18,41 @@ type Orthotope struct {
 
 var ACCURACY uint = 13
 
+// MAXCOORD is the largest magnitude of a coordinate in a validated Orthotope. Keeping both corners within it means the
+// distance between any two coordinates, such as the delta of bounds, fits in an int32.
+const MAXCOORD int32 = math.MaxInt32 / 2
+
+var (
+	// ErrOverflow is returned for orthotopes that reach beyond MAXCOORD.
+	ErrOverflow = errors.New("rect: coordinate beyond MAXCOORD")
+	// ErrNegativeDelta is returned for orthotopes with a negative delta.
+	ErrNegativeDelta = errors.New("rect: negative delta")
+)
+
+// NewOrthotope returns an orthotope at point with size delta, or an error if it is invalid (see Validate).
+func NewOrthotope(point, delta [DIMENSIONS]int32) (*Orthotope, error) {
+	o := &Orthotope{Point: point, Delta: delta}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Validate returns ErrNegativeDelta or ErrOverflow unless both corners of o are within MAXCOORD. Overlaps, Contains
+// and MinBounds of valid orthotopes do not overflow.
+func (o *Orthotope) Validate() error {
+	for d, p := range o.Point {
+		if o.Delta[d] < 0 {
+			return fmt.Errorf("%w: %d in dimension %d", ErrNegativeDelta, o.Delta[d], d)
+		}
+		// Compare against MAXCOORD - p, since p + delta may itself overflow.
+		if p < -MAXCOORD || p > MAXCOORD || o.Delta[d] > MAXCOORD-p {
+			return fmt.Errorf("%w: point %d, delta %d in dimension %d", ErrOverflow, p, o.Delta[d], d)
+		}
+	}
+	return nil
+}
+
 func (o *Orthotope) Overlaps(orth *Orthotope) bool {
 	intersects := true
 	for index, p0 := range orth.Point {
@@ -93,31 +129,40 @@ func (o *Orthotope) MinBounds(others ...*Orthotope) {
 	}
 }
 
+// Volume is the product of the deltas, saturating at math.MaxInt32 rather than overflowing.
 func (o *Orthotope) Volume() int32 {
 	v := int32(1)
 	for _, d := range o.Delta {
-		v *= d
+		v = disc.MulSat(v, d)
 	}
 	return v
 }
 
+// SurfaceArea sums the areas of the faces, saturating at math.MaxInt32 rather than overflowing.
 func (o *Orthotope) SurfaceArea() int32 {
 	if DIMENSIONS == 1 {
 		return 0
 	}
 
-	v := o.Volume()
+	// Multiply the other deltas for each face, rather than dividing the volume, which fails for zero deltas.
 	sa := int32(0)
 	for i := 0; i < DIMENSIONS; i++ {
-		sa += v / o.Delta[i]
+		face := int32(1)
+		for j, d := range o.Delta {
+			if j != i {
+				face = disc.MulSat(face, d)
+			}
+		}
+		sa = disc.AddSat(sa, face)
 	}
-	return 2 * sa
+	return disc.MulSat(2, sa)
 }
 
+// Score is the sum of the deltas, saturating at math.MaxInt32 rather than overflowing.
 func (o *Orthotope) Score() int32 {
 	score := int32(0)
 	for _, d := range o.Delta {
-		score += d
+		score = disc.AddSat(score, d)
 	}
 	return score
 }
@@ -2,6 +2,8 @@
 package rect
 
 import (
+	"errors"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -142,3 +144,66 @@ func TestOrthEquals(t *testing.T) {
 		t.Errorf("%v should not equal %v", o1, o2)
 	}
 }
+
+func TestValidate(t *testing.T) {
+	valid := []*Orthotope{
+		{Point: [d]int32{-MAXCOORD, -MAXCOORD, -MAXCOORD}, Delta: [d]int32{2 * MAXCOORD, 2 * MAXCOORD, 2 * MAXCOORD}},
+		{Point: [d]int32{MAXCOORD, 0, -MAXCOORD}, Delta: [d]int32{0, MAXCOORD, 0}},
+	}
+	for _, o := range valid {
+		if created, err := NewOrthotope(o.Point, o.Delta); err != nil || !created.Equals(o) {
+			t.Errorf("Unable to create %v: %v", o, err)
+		}
+	}
+
+	invalid := []struct {
+		orth *Orthotope
+		err  error
+	}{
+		{&Orthotope{Delta: [d]int32{0, 0, -1}}, ErrNegativeDelta},
+		{&Orthotope{Point: [d]int32{MAXCOORD}, Delta: [d]int32{1}}, ErrOverflow},
+		{&Orthotope{Point: [d]int32{0, 1}, Delta: [d]int32{0, math.MaxInt32}}, ErrOverflow},
+		{&Orthotope{Point: [d]int32{math.MinInt32}}, ErrOverflow},
+	}
+	for _, c := range invalid {
+		if created, err := NewOrthotope(c.orth.Point, c.orth.Delta); created != nil || !errors.Is(err, c.err) {
+			t.Errorf("Expected %v for %v, got %v", c.err, c.orth, err)
+		}
+	}
+}
+
+func TestExtremeOrthotopes(t *testing.T) {
+	low, _ := NewOrthotope([d]int32{-MAXCOORD, -MAXCOORD, -MAXCOORD}, [d]int32{MAXCOORD, MAXCOORD, MAXCOORD})
+	high, _ := NewOrthotope([d]int32{1, 1, 1}, [d]int32{MAXCOORD - 1, MAXCOORD - 1, MAXCOORD - 1})
+	if low.Overlaps(high) || !low.Overlaps(low) {
+		t.Errorf("Unexpected overlap between %v and %v", low, high)
+	}
+
+	bounds := &Orthotope{}
+	bounds.MinBounds(low, high)
+	if bounds.Validate() != nil || !bounds.Contains(low) || !bounds.Contains(high) {
+		t.Errorf("Bounds %v do not contain %v and %v", bounds, low, high)
+	}
+
+	for _, o := range []*Orthotope{low, bounds} {
+		if score := o.Score(); score != math.MaxInt32 {
+			t.Errorf("Expected the score of %v to saturate, got %d", o, score)
+		}
+		if volume := o.Volume(); volume != math.MaxInt32 {
+			t.Errorf("Expected the volume of %v to saturate, got %d", o, volume)
+		}
+		if area := o.SurfaceArea(); area != math.MaxInt32 {
+			t.Errorf("Expected the surface area of %v to saturate, got %d", o, area)
+		}
+	}
+
+	// Flat orthotopes have a surface area without a volume.
+	flat := &Orthotope{Delta: [d]int32{1 << 15, 1 << 15, 0}}
+	if area, volume := flat.SurfaceArea(), flat.Volume(); area != 1<<31-1 || volume != 0 {
+		t.Errorf("Expected an area of %d and no volume, got %d and %d", int32(math.MaxInt32), area, volume)
+	}
+	flat.Delta[0] = 1 << 14
+	if area := flat.SurfaceArea(); area != 1<<30 {
+		t.Errorf("Expected an area of %d, got %d", 1<<30, area)
+	}
+}
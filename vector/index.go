@@ -0,0 +1,421 @@
+// Package vector provides a bounding volume hierarchy over high dimensional points, such as word vectors, for exact
+// and approximate k nearest neighbour search. Unlike the bvh package, the number of dimensions is chosen at runtime.
+package vector
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+
+	collision "github.com/briannoyama/bvh/bvh"
+	"github.com/briannoyama/bvh/math32"
+)
+
+// Metric for comparing vectors. Distances are smaller for more similar vectors.
+type Metric int
+
+const (
+	// L2 compares vectors by their squared Euclidean distance.
+	L2 Metric = iota
+	// Cosine compares vectors by one minus the cosine of the angle between them.
+	Cosine
+	// InnerProduct compares vectors by their negated inner (dot) product.
+	InnerProduct
+)
+
+// String returns the name of the metric.
+func (m Metric) String() string {
+	switch m {
+	case L2:
+		return "L2"
+	case Cosine:
+		return "Cosine"
+	case InnerProduct:
+		return "InnerProduct"
+	}
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// DefaultLeafSize is the number of vectors in each leaf of an Index when none is given.
+const DefaultLeafSize int = 16
+
+// ErrDimensions is returned when vectors do not have the dimensions of the Index.
+var ErrDimensions = errors.New("vector: mismatched dimensions")
+
+// node of an Index. Internal nodes have two children; leaves hold the vectors from start to end in the order.
+type node struct {
+	start, end  int32
+	first, next int32
+}
+
+// Index is an immutable BVH of vectors, built top down with collision.TopDownBVH. The vectors of a branch are
+// projected onto math32.DIMENSIONS axes, each along the line between two distant vectors, and TopDownBVH orders the
+// projected points; since it splits each volume in half, the next levels of the Index halve that order in the same way
+// before projecting again. Bounding boxes are too loose in high dimensions, so each node is bounded by a sphere around
+// the mean of its vectors. Searches skip the nodes whose sphere cannot hold a closer vector. An Index may be searched
+// from multiple goroutines.
+type Index struct {
+	metric Metric
+	dims   int
+	// vectors holds the (normalized for Cosine) vectors one after another, in the order of the leaves.
+	vectors []float32
+	// order maps the position of a vector in vectors to its index in the slice given to NewIndex.
+	order []int32
+	nodes []node
+	// centers holds the center of each node one after another, and radii their radius.
+	centers  []float32
+	radii    []float32
+	leafSize int
+}
+
+// Result of a search: the index of a vector in the slice given to NewIndex and its distance from the query.
+type Result struct {
+	Index    int
+	Distance float32
+}
+
+// NewIndex creates an Index of the vectors for the metric. Each leaf holds at most leafSize vectors (DefaultLeafSize
+// when leafSize < 1). The vectors are copied, and must all have the same number of dimensions.
+func NewIndex(vectors [][]float32, metric Metric, leafSize int) (*Index, error) {
+	if leafSize < 1 {
+		leafSize = DefaultLeafSize
+	}
+	index := &Index{metric: metric, leafSize: leafSize}
+	if len(vectors) == 0 {
+		return index, nil
+	}
+	index.dims = len(vectors[0])
+
+	// Copy the vectors, normalized for Cosine such that they are split as they are compared.
+	copied := make([][]float32, len(vectors))
+	buffer := make([]float32, len(vectors)*index.dims)
+	for i, vector := range vectors {
+		if len(vector) != index.dims {
+			return nil, fmt.Errorf("%w: vector %d has %d, expected %d", ErrDimensions, i, len(vector), index.dims)
+		}
+		copied[i] = buffer[i*index.dims : (i+1)*index.dims]
+		copy(copied[i], vector)
+		if metric == Cosine {
+			normalize(copied[i])
+		}
+	}
+
+	index.order = make([]int32, len(vectors))
+	for i := range index.order {
+		index.order[i] = int32(i)
+	}
+	index.build(copied, 0, int32(len(vectors)), 0)
+	index.vectors = make([]float32, 0, len(buffer))
+	for _, i := range index.order {
+		index.vectors = append(index.vectors, copied[i]...)
+	}
+	index.centers = make([]float32, index.dims*len(index.nodes))
+	index.radii = make([]float32, len(index.nodes))
+	for n := range index.nodes {
+		index.bound(int32(n))
+	}
+	return index, nil
+}
+
+// Len returns the number of vectors in the Index.
+func (x *Index) Len() int {
+	return len(x.order)
+}
+
+// Dimensions returns the number of dimensions of the vectors in the Index.
+func (x *Index) Dimensions() int {
+	return x.dims
+}
+
+// Metric returns the metric the Index was built for.
+func (x *Index) Metric() Metric {
+	return x.metric
+}
+
+// build appends the node for the positions from start to end and its descendants, halving them like
+// collision.TopDownBVH. Every math32.DIMENSIONS levels the positions are ordered again (see sortLeaves), such that the
+// splits follow the vectors of the branch. Returns the index of the node.
+func (x *Index) build(vectors [][]float32, start, end int32, level int) int32 {
+	current := int32(len(x.nodes))
+	x.nodes = append(x.nodes, node{start: start, end: end, first: -1, next: -1})
+	if int(end-start) > x.leafSize {
+		if level%math32.DIMENSIONS == 0 {
+			sortLeaves(vectors, x.order[start:end])
+		}
+		mid := start + (end-start)/2
+		first := x.build(vectors, start, mid, level+1)
+		x.nodes[current].first, x.nodes[current].next = first, x.build(vectors, mid, end, level+1)
+	}
+	return current
+}
+
+// sortLeaves sorts part, the indices of some vectors, in the order of the leaves of a collision.TopDownBVH over their
+// projections (see project).
+func sortLeaves(vectors [][]float32, part []int32) {
+	points := make([]math32.Orthotope[float32], len(part))
+	orths := make([]*math32.Orthotope[float32], len(part))
+	indices := make(map[*math32.Orthotope[float32]]int32, len(part))
+	for i, point := range project(vectors, part) {
+		points[i].Point = point
+		orths[i] = &points[i]
+		indices[orths[i]] = part[i]
+	}
+
+	tree := collision.TopDownBVH[*math32.Orthotope[float32], float32](orths)
+	part = part[:0]
+	tree.Iterator().Walk(func(*math32.Orthotope[float32], int32) bool { return true },
+		func(leaf *math32.Orthotope[float32]) bool {
+			part = append(part, indices[leaf])
+			return true
+		})
+}
+
+// project returns the coordinates of the vectors in part along math32.DIMENSIONS orthonormal axes. Like FastMap, each
+// axis is the line between two distant vectors, once the distances along the earlier axes are removed.
+func project(vectors [][]float32, part []int32) []math32.Coordinate[float32] {
+	coords := make([]math32.Coordinate[float32], len(part))
+	axes := make([][]float32, 0, math32.DIMENSIONS)
+	// residual is the squared distance between two vectors of part that the earlier axes do not account for.
+	residual := func(a, b int) float32 {
+		distance := squaredDistance(vectors[part[a]], vectors[part[b]])
+		for k := range axes {
+			distance -= (coords[a][k] - coords[b][k]) * (coords[a][k] - coords[b][k])
+		}
+		return distance
+	}
+	farthest := func(from int) int {
+		far, farDistance := from, float32(0)
+		for i := range part {
+			if distance := residual(from, i); distance > farDistance {
+				far, farDistance = i, distance
+			}
+		}
+		return far
+	}
+
+	for k := 0; k < math32.DIMENSIONS; k++ {
+		first := farthest(0)
+		second := farthest(first)
+		axis := make([]float32, len(vectors[part[0]]))
+		for d := range axis {
+			axis[d] = vectors[part[second]][d] - vectors[part[first]][d]
+		}
+		for _, earlier := range axes {
+			along := dot(axis, earlier)
+			for d := range axis {
+				axis[d] -= along * earlier[d]
+			}
+		}
+		if normalize(axis) == 0 {
+			// The vectors lie within the earlier axes.
+			break
+		}
+		for i, v := range part {
+			coords[i][k] = dot(vectors[v], axis)
+		}
+		axes = append(axes, axis)
+	}
+	return coords
+}
+
+// bound calculates the sphere around the vectors of a node.
+func (x *Index) bound(index int32) {
+	n := x.nodes[index]
+	center := x.center(index)
+	for i := n.start; i < n.end; i++ {
+		for d, value := range x.vector(i) {
+			center[d] += value
+		}
+	}
+	for d := range center {
+		center[d] /= float32(n.end - n.start)
+	}
+	var radius float32
+	for i := n.start; i < n.end; i++ {
+		radius = max(radius, squaredDistance(center, x.vector(i)))
+	}
+	// Round up, so that the sphere is never smaller than its vectors.
+	x.radii[index] = float32(math.Sqrt(float64(radius))) * (1 + 1e-6)
+}
+
+// center of the sphere around a node.
+func (x *Index) center(index int32) []float32 {
+	return x.centers[int(index)*x.dims : int(index+1)*x.dims]
+}
+
+// vector at a position in the order of the leaves.
+func (x *Index) vector(position int32) []float32 {
+	return x.vectors[int(position)*x.dims : int(position+1)*x.dims]
+}
+
+// Search returns the k vectors nearest to the query, closest first.
+func (x *Index) Search(query []float32, k int) ([]Result, error) {
+	return x.SearchBudget(query, k, 0)
+}
+
+// SearchBudget returns up to k vectors near to the query, closest first. The search stops after comparing the query
+// with budget vectors, returning the best found so far; it is exact when budget < 1. Nodes are visited in order of
+// how close they could be, so small budgets still tend to find close vectors.
+func (x *Index) SearchBudget(query []float32, k int, budget int) ([]Result, error) {
+	if len(query) != x.dims && x.Len() > 0 {
+		return nil, fmt.Errorf("%w: query has %d, expected %d", ErrDimensions, len(query), x.dims)
+	}
+	if k < 1 || x.Len() == 0 {
+		return nil, nil
+	}
+	if x.metric == Cosine {
+		query = append([]float32(nil), query...)
+		normalize(query)
+	}
+
+	best := &results{}
+	open := &frontier{{index: 0, bound: x.lowerBound(query, 0)}}
+	compared := 0
+	for open.Len() > 0 {
+		next := heap.Pop(open).(candidate)
+		if best.Len() == k && next.bound >= (*best)[0].Distance {
+			break
+		}
+		n := x.nodes[next.index]
+		if n.first >= 0 {
+			heap.Push(open, candidate{index: n.first, bound: x.lowerBound(query, n.first)})
+			heap.Push(open, candidate{index: n.next, bound: x.lowerBound(query, n.next)})
+			continue
+		}
+		for position := n.start; position < n.end; position++ {
+			distance := x.distance(query, x.vector(position))
+			if best.Len() < k {
+				heap.Push(best, Result{Index: int(x.order[position]), Distance: distance})
+			} else if distance < (*best)[0].Distance {
+				(*best)[0] = Result{Index: int(x.order[position]), Distance: distance}
+				heap.Fix(best, 0)
+			}
+		}
+		if compared += int(n.end - n.start); budget > 0 && compared >= budget {
+			break
+		}
+	}
+
+	sorted := make([]Result, best.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(best).(Result)
+	}
+	return sorted, nil
+}
+
+// distance between the query and a vector under the metric of the Index.
+func (x *Index) distance(query, vector []float32) float32 {
+	switch x.metric {
+	case L2:
+		return squaredDistance(query, vector)
+	case Cosine:
+		return 1 - dot(query, vector)
+	}
+	return -dot(query, vector)
+}
+
+// lowerBound returns a distance no greater than that from the query to any vector within the sphere of the node.
+func (x *Index) lowerBound(query []float32, index int32) float32 {
+	center, radius := x.center(index), x.radii[index]
+	if x.metric == InnerProduct {
+		// The largest product within the sphere is in the direction of the query.
+		return -(dot(query, center) + radius*float32(math.Sqrt(float64(dot(query, query)))))
+	}
+
+	gap := max(float32(math.Sqrt(float64(squaredDistance(query, center))))-radius, 0)
+	if x.metric == Cosine {
+		// For unit vectors, 1 - a·b = |a - b|² / 2.
+		return gap * gap / 2
+	}
+	return gap * gap
+}
+
+// BruteForce returns the k vectors nearest to the query by comparing it with each vector. It is the reference that
+// Search is tested and benchmarked against.
+func BruteForce(vectors [][]float32, metric Metric, query []float32, k int) []Result {
+	x := &Index{metric: metric}
+	if metric == Cosine {
+		query = append([]float32(nil), query...)
+		normalize(query)
+	}
+	best := &results{}
+	for i, vector := range vectors {
+		if metric == Cosine {
+			vector = append([]float32(nil), vector...)
+			normalize(vector)
+		}
+		distance := x.distance(query, vector)
+		if best.Len() < k {
+			heap.Push(best, Result{Index: i, Distance: distance})
+		} else if k > 0 && distance < (*best)[0].Distance {
+			(*best)[0] = Result{Index: i, Distance: distance}
+			heap.Fix(best, 0)
+		}
+	}
+	sorted := make([]Result, best.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(best).(Result)
+	}
+	return sorted
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i, value := range a {
+		sum += value * b[i]
+	}
+	return sum
+}
+
+func squaredDistance(a, b []float32) float32 {
+	var sum float32
+	for i, value := range a {
+		sum += (value - b[i]) * (value - b[i])
+	}
+	return sum
+}
+
+// normalize scales the vector to unit length, returning its previous length. Zero vectors are left unchanged.
+func normalize(vector []float32) float32 {
+	length := float32(math.Sqrt(float64(dot(vector, vector))))
+	if length == 0 {
+		return 0
+	}
+	for i := range vector {
+		vector[i] /= length
+	}
+	return length
+}
+
+// candidate is a node to visit and the lower bound of its distance to the query.
+type candidate struct {
+	index int32
+	bound float32
+}
+
+// frontier is a min heap of candidates.
+type frontier []candidate
+
+func (f frontier) Len() int           { return len(f) }
+func (f frontier) Less(i, j int) bool { return f[i].bound < f[j].bound }
+func (f frontier) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(c any)        { *f = append(*f, c.(candidate)) }
+func (f *frontier) Pop() any {
+	last := (*f)[len(*f)-1]
+	*f = (*f)[:len(*f)-1]
+	return last
+}
+
+// results is a max heap of the k best results, so that the worst may be replaced.
+type results []Result
+
+func (r results) Len() int           { return len(r) }
+func (r results) Less(i, j int) bool { return r[i].Distance > r[j].Distance }
+func (r results) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r *results) Push(c any)        { *r = append(*r, c.(Result)) }
+func (r *results) Pop() any {
+	last := (*r)[len(*r)-1]
+	*r = (*r)[:len(*r)-1]
+	return last
+}
@@ -0,0 +1,191 @@
+package vector
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// clustered returns n vectors gathered around a few centers, which resembles embeddings more than uniform noise. The
+// centers are the same for each seed.
+func clustered(n, dims int, seed int64) [][]float32 {
+	r := rand.New(rand.NewSource(0))
+	centers := make([][]float32, 20)
+	for c := range centers {
+		centers[c] = make([]float32, dims)
+		for d := range centers[c] {
+			centers[c][d] = float32(r.NormFloat64())
+		}
+	}
+	r = rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		center := centers[r.Intn(len(centers))]
+		vectors[i] = make([]float32, dims)
+		for d := range vectors[i] {
+			vectors[i][d] = center[d] + 0.3*float32(r.NormFloat64())
+		}
+	}
+	return vectors
+}
+
+func closeTo(a, b float32) bool {
+	return math.Abs(float64(a-b)) <= 1e-4*math.Max(1, math.Abs(float64(b)))
+}
+
+func TestSearch(t *testing.T) {
+	vectors := clustered(3000, 64, 1)
+	queries := clustered(50, 64, 2)
+	for _, metric := range []Metric{L2, Cosine, InnerProduct} {
+		index, err := NewIndex(vectors, metric, 0)
+		if err != nil {
+			t.Fatalf("Unable to create an index for %v: %v", metric, err)
+		}
+		if index.Len() != len(vectors) || index.Dimensions() != 64 || index.Metric() != metric {
+			t.Errorf("Unexpected index: %d vectors of %d dimensions for %v", index.Len(), index.Dimensions(),
+				index.Metric())
+		}
+		for _, query := range queries {
+			expected := BruteForce(vectors, metric, query, 10)
+			found, err := index.Search(query, 10)
+			if err != nil || len(found) != len(expected) {
+				t.Fatalf("Search for %v returned %d results: %v", metric, len(found), err)
+			}
+			for i := range found {
+				if !closeTo(found[i].Distance, expected[i].Distance) {
+					t.Errorf("Result %d for %v was %v, expected %v", i, metric, found[i], expected[i])
+				}
+				if actual := index.distance(query, normalized(metric, vectors[found[i].Index])); metric != Cosine &&
+					!closeTo(actual, found[i].Distance) {
+					t.Errorf("Result %v for %v is at distance %v", found[i], metric, actual)
+				}
+			}
+		}
+	}
+}
+
+func normalized(metric Metric, vector []float32) []float32 {
+	if metric == Cosine {
+		vector = append([]float32(nil), vector...)
+		normalize(vector)
+	}
+	return vector
+}
+
+func TestSearchBudget(t *testing.T) {
+	vectors := clustered(3000, 100, 3)
+	queries := clustered(50, 100, 4)
+	index, _ := NewIndex(vectors, L2, 8)
+
+	recall := 0
+	for _, query := range queries {
+		expected := BruteForce(vectors, L2, query, 10)
+		found, _ := index.SearchBudget(query, 10, 300)
+		if len(found) != 10 {
+			t.Fatalf("Expected 10 results, got %d", len(found))
+		}
+		inExpected := map[int]bool{}
+		for _, result := range expected {
+			inExpected[result.Index] = true
+		}
+		for i, result := range found {
+			if i > 0 && result.Distance < found[i-1].Distance {
+				t.Errorf("Results are not sorted: %v", found)
+			}
+			if !closeTo(result.Distance, squaredDistance(query, vectors[result.Index])) {
+				t.Errorf("Result %v has the wrong distance", result)
+			}
+			if inExpected[result.Index] {
+				recall++
+			}
+		}
+
+		// A budget of every vector is exact.
+		exact, _ := index.SearchBudget(query, 10, len(vectors))
+		for i := range exact {
+			if !closeTo(exact[i].Distance, expected[i].Distance) {
+				t.Errorf("Result %d with a full budget was %v, expected %v", i, exact[i], expected[i])
+			}
+		}
+	}
+	if recall < len(queries)*10/2 {
+		t.Errorf("Expected a recall of at least 50%% with a budget of 10%%, got %d/%d", recall, len(queries)*10)
+	}
+}
+
+func TestCosineNormalized(t *testing.T) {
+	vectors := clustered(2000, 50, 7)
+	scaled := make([][]float32, len(vectors))
+	r := rand.New(rand.NewSource(8))
+	for i, vector := range vectors {
+		// Powers of two normalize to the same unit vectors.
+		factor := float32(math.Ldexp(1, r.Intn(20)-10))
+		scaled[i] = make([]float32, len(vector))
+		for d, value := range vector {
+			scaled[i][d] = value * factor
+		}
+	}
+	index, _ := NewIndex(vectors, Cosine, 0)
+	scaledIndex, _ := NewIndex(scaled, Cosine, 0)
+	if !reflect.DeepEqual(index.order, scaledIndex.order) || !reflect.DeepEqual(index.radii, scaledIndex.radii) {
+		t.Errorf("Expected the lengths of the vectors not to change a Cosine index")
+	}
+}
+
+func TestIndexErrors(t *testing.T) {
+	if _, err := NewIndex([][]float32{{1, 2}, {1, 2, 3}}, L2, 0); !errors.Is(err, ErrDimensions) {
+		t.Errorf("Expected ErrDimensions for mismatched vectors, got %v", err)
+	}
+	index, _ := NewIndex([][]float32{{1, 2}, {3, 4}}, L2, 0)
+	if _, err := index.Search([]float32{1}, 1); !errors.Is(err, ErrDimensions) {
+		t.Errorf("Expected ErrDimensions for a mismatched query, got %v", err)
+	}
+	if found, _ := index.Search([]float32{3, 3}, 5); len(found) != 2 || found[0].Index != 1 {
+		t.Errorf("Unexpected results: %v", found)
+	}
+
+	empty, err := NewIndex(nil, Cosine, 0)
+	if err != nil || empty.Len() != 0 {
+		t.Errorf("Unable to create an empty index: %v", err)
+	}
+	if found, err := empty.Search([]float32{1, 2}, 3); len(found) != 0 || err != nil {
+		t.Errorf("Searching an empty index returned %v, %v", found, err)
+	}
+}
+
+func benchmarkSearch(b *testing.B, metric Metric, budget int) {
+	vectors := clustered(20000, 100, 5)
+	queries := clustered(100, 100, 6)
+	index, _ := NewIndex(vectors, metric, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.SearchBudget(queries[i%len(queries)], 10, budget)
+	}
+}
+
+func BenchmarkSearchL2(b *testing.B) {
+	benchmarkSearch(b, L2, 0)
+}
+
+func BenchmarkSearchCosine(b *testing.B) {
+	benchmarkSearch(b, Cosine, 0)
+}
+
+func BenchmarkSearchInnerProduct(b *testing.B) {
+	benchmarkSearch(b, InnerProduct, 0)
+}
+
+func BenchmarkSearchBudgetL2(b *testing.B) {
+	benchmarkSearch(b, L2, 1000)
+}
+
+func BenchmarkBruteForceL2(b *testing.B) {
+	vectors := clustered(20000, 100, 5)
+	queries := clustered(100, 100, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BruteForce(vectors, L2, queries[i%len(queries)], 10)
+	}
+}